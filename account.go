@@ -1,45 +1,152 @@
 package hdwallet
 
 import (
+	"crypto/ecdsa"
+
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/pkg/errors"
 )
 
 type walletAccount struct {
-	derivedAddrs       []*HDWalletAddress
+	derivedAddrs       *addressRegistry
+	vault              *vault
+	coinType           CoinType
 	accountIdx         int
-	accountKey         *hdkeychain.ExtendedKey
 	lastNonHardenedIdx int
 	lastHardenedIdx    int
+	legacyDerivation   bool
 }
 
-func newWalletAccount(masterKey *hdkeychain.ExtendedKey, accountIdx int, newKeyForAccount bool) *walletAccount {
-	subKey := masterKey
+// newWalletAccount creates the account at accountIdx, storing the key of its
+// address chain (m/44'/coin_type'/account'/0) in v.
+func newWalletAccount(v *vault, coinType CoinType, accountIdx int, newKeyForAccount, legacyDerivation bool) (*walletAccount, error) {
+	firstPath, err := addressDerivationPathFromIdx(coinType, accountIdx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// derivation paths are m/44'/coin_type'/account'/0/address,
+	// so everything but the final step can be derived once and cached.
+	chainPath := firstPath[:len(firstPath)-1]
+
+	var chainKey *hdkeychain.ExtendedKey
+
+	err = v.withMaster(func(masterKey *hdkeychain.ExtendedKey) error {
+		subKey := masterKey
+
+		if newKeyForAccount {
+			subKey, err = deriveExtendedKey(masterKey, firstPath, legacyDerivation)
+			if err != nil {
+				return errors.Wrap(err, "error deriving new key for account")
+			}
+
+			defer subKey.Zero()
+		}
+
+		accountKey, err := deriveExtendedKey(subKey, chainPath[:len(chainPath)-1], legacyDerivation)
+		if err != nil {
+			return errors.Wrap(err, "error deriving account Extended Key")
+		}
 
-	if newKeyForAccount {
-		derivePath := addressDerivationPathFromIdx(accountIdx, 0)
+		defer accountKey.Zero()
 
-		for _, n := range derivePath {
-			subKey, _ = subKey.Derive(n)
+		chainKey, err = deriveExtendedKey(accountKey, chainPath[len(chainPath)-1:], legacyDerivation)
+		if err != nil {
+			return errors.Wrap(err, "error deriving chain Extended Key")
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &walletAccount{
-		accountIdx:      accountIdx,
-		accountKey:      subKey,
-		lastHardenedIdx: hdkeychain.HardenedKeyStart,
+	// the vault keeps its own copy of the chain key.
+	defer chainKey.Zero()
+
+	if err = v.setChainKey(chainKey); err != nil {
+		return nil, err
 	}
+
+	return &walletAccount{
+		derivedAddrs:     newAddressRegistry(),
+		vault:            v,
+		coinType:         coinType,
+		accountIdx:       accountIdx,
+		lastHardenedIdx:  hdkeychain.HardenedKeyStart,
+		legacyDerivation: legacyDerivation,
+	}, nil
 }
 
+// derive derives and records the address at addressIdx,
+// returning the already recorded address if it was derived before.
 func (w *walletAccount) derive(addressIdx int) (*HDWalletAddress, error) {
-	derived, err := deriveNewAdressFromAccountKey(w.accountKey, w.accountIdx, addressIdx)
+	if path, err := addressDerivationPathFromIdx(w.coinType, w.accountIdx, addressIdx); err == nil {
+		if existing, ok := w.derivedAddrs.findByPath(path); ok {
+			return existing, nil
+		}
+	}
+
+	derived, err := w.deriveRaw(addressIdx)
 	if err != nil {
-		return nil, errors.Wrap(err, "error deriving new child account")
+		return nil, err
 	}
 
-	fancyDerived := newWalletAddress(derived.PrivKey, derived.PubKey, derived.Address, w.accountIdx, addressIdx)
+	if err := w.vault.register(derived.PrivKey); err != nil {
+		wipeBigInt(derived.PrivKey)
+		return nil, err
+	}
+
+	fancyDerived := newWalletAddress(derived.PrivKey, derived.PubKey, derived.Address, w.coinType, w.accountIdx, addressIdx)
+	fancyDerived.vault = w.vault
+
+	return w.derivedAddrs.add(fancyDerived), nil
+}
+
+// deriveUnrecorded derives the address at addressIdx without
+// adding it to the account's derived addresses.
+// The address doesn't hold its private key, which is derived again using the vault's
+// chain key whenever it's needed, so nothing is left to wipe when the wallet is locked.
+func (w *walletAccount) deriveUnrecorded(addressIdx int) (*HDWalletAddress, error) {
+	derived, err := w.deriveRaw(addressIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	wipeBigInt(derived.PrivKey)
 
-	w.derivedAddrs = append(w.derivedAddrs, fancyDerived)
+	addr := newWalletAddress(nil, derived.PubKey, derived.Address, w.coinType, w.accountIdx, addressIdx)
+	addr.vault = w.vault
+	addr.unrecorded = w
+
+	return addr, nil
+}
+
+// withPrivateKey calls f with the private key of the address at addressIdx, derived
+// while holding the vault's read lock, or returns ErrLocked. The key is wiped once f returns.
+func (w *walletAccount) withPrivateKey(addressIdx int, f func(*ecdsa.PrivateKey) error) error {
+	return w.vault.withChain(func(chainKey *hdkeychain.ExtendedKey) error {
+		derived, err := deriveNewAddressFromChainKey(chainKey, addressIdx, w.legacyDerivation)
+		if err != nil {
+			return errors.Wrap(err, "error deriving private key")
+		}
+
+		defer wipeBigInt(derived.PrivKey)
+
+		return f(derived.PrivKey)
+	})
+}
+
+func (w *walletAccount) deriveRaw(addressIdx int) (*rawDerived, error) {
+	var derived *rawDerived
+
+	err := w.vault.withChain(func(chainKey *hdkeychain.ExtendedKey) (err error) {
+		derived, err = deriveNewAddressFromChainKey(chainKey, addressIdx, w.legacyDerivation)
+		return errors.Wrap(err, "error deriving new child account")
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return fancyDerived, nil
+	return derived, nil
 }
@@ -0,0 +1,137 @@
+package hdwallet
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// maxDerivationIdx is one past the largest (hardened) derivation index.
+const maxDerivationIdx int64 = 1 << 32
+
+// streamBufferSize is the channel buffer size used by StreamAddresses.
+const streamBufferSize int = 64
+
+func validateDerivationRange(start, end int) error {
+	if start < 0 || end < start || int64(end) > maxDerivationIdx {
+		return errors.Errorf("invalid derivation index range [%d, %d)", start, end)
+	}
+
+	return nil
+}
+
+// DeriveRange derives the child accounts for every derivation index in [start, end),
+// which can include hardened indices.
+// Like DeriveAddressFromIndex, all derived addresses are kept by the wallet
+// and returned by Accounts(); use Iterate or StreamAddresses
+// when deriving large numbers of addresses.
+func (w *HDWallet) DeriveRange(start, end int) ([]*HDWalletAddress, error) {
+	if err := validateDerivationRange(start, end); err != nil {
+		return nil, err
+	}
+
+	derived := make([]*HDWalletAddress, 0, end-start)
+
+	for idx := start; idx < end; idx++ {
+		fancyDerived, err := w.account.derive(idx)
+		if err != nil {
+			return nil, err
+		}
+
+		derived = append(derived, fancyDerived)
+	}
+
+	return derived, nil
+}
+
+// AddressIterator lazily derives addresses for a range of derivation indices.
+// Addresses yielded by an AddressIterator are not kept by the wallet, and don't hold
+// their private key, which is derived again whenever it's needed.
+type AddressIterator struct {
+	account *walletAccount
+	next    int
+	end     int
+	current *HDWalletAddress
+	err     error
+}
+
+// Iterate returns an AddressIterator over the derivation indices in [start, end).
+//
+//	it := w.Iterate(0, 1_000_000)
+//	for it.Next() {
+//		addr := it.Address()
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle err
+//	}
+func (w *HDWallet) Iterate(start, end int) *AddressIterator {
+	return &AddressIterator{
+		account: w.account,
+		next:    start,
+		end:     end,
+		err:     validateDerivationRange(start, end),
+	}
+}
+
+// Next derives the next address in the iterator's range, returning false
+// once the range is exhausted or an error occurs.
+func (it *AddressIterator) Next() bool {
+	if it.err != nil || it.next >= it.end {
+		it.current = nil
+		return false
+	}
+
+	it.current, it.err = it.account.deriveUnrecorded(it.next)
+	if it.err != nil {
+		it.current = nil
+		return false
+	}
+
+	it.next++
+
+	return true
+}
+
+// Address returns the address derived by the last call to Next.
+func (it *AddressIterator) Address() *HDWalletAddress {
+	return it.current
+}
+
+// Err returns the first error encountered by the iterator, if any.
+func (it *AddressIterator) Err() error {
+	return it.err
+}
+
+// StreamAddresses derives addresses for the derivation indices in [start, end)
+// in a separate goroutine, sending them on the returned address channel.
+// Both channels are closed once the range is exhausted, ctx is cancelled
+// or an error occurs, in which case the error is sent on the error channel.
+// Streamed addresses are not kept by the wallet.
+func (w *HDWallet) StreamAddresses(ctx context.Context, start, end int) (<-chan *HDWalletAddress, <-chan error) {
+	var (
+		addrCh = make(chan *HDWalletAddress, streamBufferSize)
+		errCh  = make(chan error, 1)
+	)
+
+	go func() {
+		defer close(errCh)
+		defer close(addrCh)
+
+		it := w.Iterate(start, end)
+
+		for it.Next() {
+			select {
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			case addrCh <- it.Address():
+			}
+		}
+
+		if err := it.Err(); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return addrCh, errCh
+}
@@ -0,0 +1,117 @@
+package hdwallet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+func TestHDWallet_DeriveRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   int
+		end     int
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"empty range", 5, 5, assert.NoError},
+		{"first ten", 0, 10, assert.NoError},
+		{"offset", 45, 50, assert.NoError},
+		{"inverted range", 10, 5, assert.Error},
+		{"negative start", -1, 5, assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicA))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := w.DeriveRange(tt.start, tt.end)
+			if !tt.wantErr(t, err, fmt.Sprintf("DeriveRange(%v, %v)", tt.start, tt.end)) || err != nil {
+				return
+			}
+
+			assert.Len(t, got, tt.end-tt.start)
+			assert.Len(t, w.Accounts(), tt.end-tt.start)
+
+			for i, addr := range got {
+				want, err := w.DeriveAddressFromIndex(tt.start + i)
+				assert.NoError(t, err)
+				assert.Equal(t, want.Address(), addr.Address())
+				assert.Equal(t, tt.start+i, addr.DerivationIndex())
+			}
+		})
+	}
+}
+
+func TestHDWallet_Iterate(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicA))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := w.DeriveAddressFromIndex(49)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		n  int
+		it = w.Iterate(40, 60)
+	)
+
+	for it.Next() {
+		if it.Address().DerivationIndex() == 49 {
+			assert.Equal(t, want.Address(), it.Address().Address())
+		}
+
+		n++
+	}
+
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 20, n)
+	assert.Len(t, w.Accounts(), 1, "iterated addresses must not be kept by the wallet")
+
+	it = w.Iterate(10, 0)
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
+
+func TestHDWallet_StreamAddresses(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicA))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("full range", func(t *testing.T) {
+		addrCh, errCh := w.StreamAddresses(context.Background(), 0, 100)
+
+		var n int
+		for addr := range addrCh {
+			assert.Equal(t, n, addr.DerivationIndex())
+			n++
+		}
+
+		assert.NoError(t, <-errCh)
+		assert.Equal(t, 100, n)
+		assert.Empty(t, w.Accounts())
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		addrCh, errCh := w.StreamAddresses(ctx, 0, 1_000_000)
+
+		<-addrCh
+		cancel()
+
+		for range addrCh {
+		}
+
+		assert.ErrorIs(t, <-errCh, context.Canceled)
+	})
+}
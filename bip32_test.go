@@ -0,0 +1,222 @@
+package hdwallet_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+const (
+	bip32TestVector1Seed string = "000102030405060708090a0b0c0d0e0f"
+	bip32TestVector2Seed string = "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542"
+	bip32TestVector3Seed string = "4b381541583be4423346c643850da4b320e46a87ae3d2a4e6da11eba819cd4acba45d239319ac14f863b8d5ab5a0d0c64d2e8a1e7d1457df2e5a3c51c73235be"
+
+	// testMnemonicIssue172 has a private key with a leading zero byte in the
+	// hardened part of m/44'/60'/0'/0/0, so standard and legacy derivation differ.
+	testMnemonicIssue172 string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon ability around"
+)
+
+// TestBIP32Vectors checks derivation against the official BIP32 test vectors
+// (see https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki#test-vectors).
+func TestBIP32Vectors(t *testing.T) {
+	tests := []struct {
+		seed     string
+		path     string
+		wantPub  string
+		wantPriv string
+	}{
+		{
+			bip32TestVector1Seed,
+			"m",
+			"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+			"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+		},
+		{
+			bip32TestVector1Seed,
+			"m/0'",
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+			"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7",
+		},
+		{
+			bip32TestVector1Seed,
+			"m/0'/1",
+			"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+			"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs",
+		},
+		{
+			bip32TestVector1Seed,
+			"m/0'/1/2'",
+			"xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5",
+			"xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM",
+		},
+		{
+			bip32TestVector1Seed,
+			"m/0'/1/2'/2",
+			"xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV",
+			"xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334",
+		},
+		{
+			bip32TestVector1Seed,
+			"m/0'/1/2'/2/1000000000",
+			"xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy",
+			"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76",
+		},
+		{
+			bip32TestVector2Seed,
+			"m",
+			"xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB",
+			"xprv9s21ZrQH143K31xYSDQpPDxsXRTUcvj2iNHm5NUtrGiGG5e2DtALGdso3pGz6ssrdK4PFmM8NSpSBHNqPqm55Qn3LqFtT2emdEXVYsCzC2U",
+		},
+		{
+			bip32TestVector2Seed,
+			"m/0/2147483647'",
+			"xpub6ASAVgeehLbnwdqV6UKMHVzgqAG8Gr6riv3Fxxpj8ksbH9ebxaEyBLZ85ySDhKiLDBrQSARLq1uNRts8RuJiHjaDMBU4Zn9h8LZNnBC5y4a",
+			"xprv9wSp6B7kry3Vj9m1zSnLvN3xH8RdsPP1Mh7fAaR7aRLcQMKTR2vidYEeEg2mUCTAwCd6vnxVrcjfy2kRgVsFawNzmjuHc2YmYRmagcEPdU9",
+		},
+		{
+			bip32TestVector2Seed,
+			"m/0/2147483647'/1/2147483646'/2",
+			"xpub6FnCn6nSzZAw5Tw7cgR9bi15UV96gLZhjDstkXXxvCLsUXBGXPdSnLFbdpq8p9HmGsApME5hQTZ3emM2rnY5agb9rXpVGyy3bdW6EEgAtqt",
+			"xprvA2nrNbFZABcdryreWet9Ea4LvTJcGsqrMzxHx98MMrotbir7yrKCEXw7nadnHM8Dq38EGfSh6dqA9QWTyefMLEcBYJUuekgW4BYPJcr9E7j",
+		},
+		// test vector 3 covers the retention of leading zeros.
+		{
+			bip32TestVector3Seed,
+			"m/0'",
+			"xpub68NZiKmJWnxxS6aaHmn81bvJeTESw724CRDs6HbuccFQN9Ku14VQrADWgqbhhTHBaohPX4CjNLf9fq9MYo6oDaPPLPxSb7gwQN3ih19Zm4Y",
+			"xprv9uPDJpEQgRQfDcW7BkF7eTya6RPxXeJCqCJGHuCJ4GiRVLzkTXBAJMu2qaMWPrS7AANYqdq6vcBcBUdJCVVFceUvJFjaPdGZ2y9WACViL4L",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			seed, err := hex.DecodeString(tt.seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w, err := hdwallet.NewHDWallet(hdwallet.WithSeed(seed))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := w.DeriveExtendedKey(tt.path)
+			if !assert.NoError(t, err, fmt.Sprintf("DeriveExtendedKey(%v)", tt.path)) {
+				return
+			}
+
+			assert.Equal(t, tt.wantPriv, got.String())
+
+			gotPub, err := got.Neuter()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPub, gotPub.String())
+		})
+	}
+}
+
+func TestWithLegacyDerivation(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		legacy   bool
+		want     common.Address
+	}{
+		{
+			"standard",
+			testMnemonicIssue172,
+			false,
+			common.HexToAddress("0x6d6Ca8Ff678DD329b6Bd7648431Cb6fc432A4DB1"),
+		},
+		{
+			"legacy",
+			testMnemonicIssue172,
+			true,
+			common.HexToAddress("0xAD8401f58F72b0c5A374Cfd95Bb95671ddf011f6"),
+		},
+		{
+			"unaffected mnemonic, standard",
+			testMnemonicZero,
+			false,
+			common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"),
+		},
+		{
+			"unaffected mnemonic, legacy",
+			testMnemonicZero,
+			true,
+			common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(tt.mnemonic), hdwallet.WithLegacyDerivation(tt.legacy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := w.DeriveAddress()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got.Address())
+
+			if !tt.legacy {
+				assert.Equal(t, addressAtPath(t, tt.mnemonic, got.DerivationPath()), got.Address())
+			}
+		})
+	}
+}
+
+func TestWithMasterKey(t *testing.T) {
+	fromMnemonic, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := fromMnemonic.DeriveExtendedKey("m/44'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xpub, err := fromMnemonic.MasterKey().Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"master xprv", fromMnemonic.MasterKey().String(), assert.NoError},
+		{"master xpub", xpub.String(), assert.Error},
+		{"child xprv", child.String(), assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := hdwallet.ParseExtendedKey(tt.key)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			w, err := hdwallet.NewHDWallet(hdwallet.WithMasterKey(key))
+			if !tt.wantErr(t, err, fmt.Sprintf("NewHDWallet(WithMasterKey(%s))", tt.key)) || err != nil {
+				return
+			}
+
+			assert.Empty(t, w.Mnemonic())
+			assert.Empty(t, w.Seed())
+
+			got, err := w.DeriveAddress()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), got.Address())
+		})
+	}
+}
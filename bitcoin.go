@@ -0,0 +1,333 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// BitcoinAddressType represents the kind of Bitcoin address derived by
+// HDWallet.DeriveBitcoinAddress, each of which is tied to a BIP43 purpose.
+type BitcoinAddressType uint32
+
+const (
+	// BitcoinP2PKH derives legacy pay-to-pubkey-hash addresses
+	// using BIP44 derivation paths (m/44'/0'/account'/change/index).
+	BitcoinP2PKH BitcoinAddressType = 44
+	// BitcoinP2SHP2WPKH derives nested segwit (pay-to-witness-pubkey-hash wrapped in pay-to-script-hash)
+	// addresses using BIP49 derivation paths (m/49'/0'/account'/change/index).
+	BitcoinP2SHP2WPKH BitcoinAddressType = 49
+	// BitcoinP2WPKH derives native segwit pay-to-witness-pubkey-hash addresses
+	// using BIP84 derivation paths (m/84'/0'/account'/change/index).
+	BitcoinP2WPKH BitcoinAddressType = 84
+	// BitcoinP2TR derives key-path-only taproot addresses
+	// using BIP86 derivation paths (m/86'/0'/account'/change/index).
+	BitcoinP2TR BitcoinAddressType = 86
+)
+
+const (
+	bitcoinReceiveChain uint32 = 0
+	bitcoinChangeChain  uint32 = 1
+)
+
+// Purpose returns the BIP43 purpose used in derivation paths for the address type.
+func (t BitcoinAddressType) Purpose() uint32 {
+	return uint32(t)
+}
+
+func (t BitcoinAddressType) String() string {
+	switch t {
+	case BitcoinP2PKH:
+		return "p2pkh"
+	case BitcoinP2SHP2WPKH:
+		return "p2sh-p2wpkh"
+	case BitcoinP2WPKH:
+		return "p2wpkh"
+	case BitcoinP2TR:
+		return "p2tr"
+	default:
+		return fmt.Sprintf("BitcoinAddressType(%d)", uint32(t))
+	}
+}
+
+func (t BitcoinAddressType) valid() bool {
+	switch t {
+	case BitcoinP2PKH, BitcoinP2SHP2WPKH, BitcoinP2WPKH, BitcoinP2TR:
+		return true
+	default:
+		return false
+	}
+}
+
+// BitcoinAddress represents a Bitcoin address derived from an HDWallet's seed
+// using one of the BIP44/49/84/86 derivation schemes.
+// Its private key is held by the wallet, so it's wiped while the wallet is locked.
+type BitcoinAddress struct {
+	address        btcutil.Address
+	vault          *vault
+	privateKey     *ecdsa.PrivateKey // registered with vault
+	publicKey      *btcec.PublicKey
+	addressType    BitcoinAddressType
+	derivationPath accounts.DerivationPath
+	netParams      *chaincfg.Params
+}
+
+// bitcoinRegistry holds a wallet's derived Bitcoin addresses by derivation path,
+// so the private key of each path is only registered with the wallet's vault once.
+type bitcoinRegistry struct {
+	mu     sync.Mutex
+	byPath map[string]*BitcoinAddress
+}
+
+func newBitcoinRegistry() *bitcoinRegistry {
+	return &bitcoinRegistry{
+		byPath: make(map[string]*BitcoinAddress),
+	}
+}
+
+// DeriveBitcoinAddress derives the Bitcoin address of the passed type
+// at m/purpose'/coin_type'/accountIdx'/change/addressIdx, where change
+// selects the internal (change) chain instead of the external (receive) chain.
+// Deriving the same address again returns the address derived the first time.
+func (w *HDWallet) DeriveBitcoinAddress(addrType BitcoinAddressType, accountIdx int, change bool, addressIdx int) (*BitcoinAddress, error) {
+	if !addrType.valid() {
+		return nil, errors.Errorf("unsupported bitcoin address type %d", uint32(addrType))
+	}
+
+	if accountIdx < 0 || isHardenedIdx(accountIdx) {
+		return nil, errors.Errorf("invalid bitcoin account index %d", accountIdx)
+	}
+
+	if addressIdx < 0 || isHardenedIdx(addressIdx) {
+		return nil, errors.Errorf("invalid bitcoin address index %d", addressIdx)
+	}
+
+	chain := bitcoinReceiveChain
+	if change {
+		chain = bitcoinChangeChain
+	}
+
+	path := append(
+		bitcoinAccountPath(addrType, w.netParams, accountIdx),
+		chain,
+		uint32(addressIdx),
+	)
+
+	w.bitcoinAddrs.mu.Lock()
+	defer w.bitcoinAddrs.mu.Unlock()
+
+	if existing, ok := w.bitcoinAddrs.byPath[path.String()]; ok {
+		return existing, nil
+	}
+
+	var derivedKey *hdkeychain.ExtendedKey
+
+	err := w.vault.withMaster(func(masterKey *hdkeychain.ExtendedKey) (err error) {
+		derivedKey, err = deriveExtendedKey(masterKey, path, w.legacyDerivation)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer derivedKey.Zero()
+
+	privKey, err := derivedKey.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	defer privKey.Zero()
+
+	pubKey := privKey.PubKey()
+
+	address, err := bitcoinAddressFromPubKey(addrType, pubKey, w.netParams)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating %s address", addrType)
+	}
+
+	key := privKey.ToECDSA()
+	if err := w.vault.register(key); err != nil {
+		wipeBigInt(key)
+		return nil, err
+	}
+
+	addr := &BitcoinAddress{
+		address:        address,
+		vault:          w.vault,
+		privateKey:     key,
+		publicKey:      pubKey,
+		addressType:    addrType,
+		derivationPath: path,
+		netParams:      w.netParams,
+	}
+
+	w.bitcoinAddrs.byPath[path.String()] = addr
+
+	return addr, nil
+}
+
+// BitcoinAccountXPub returns the serialized extended public key of the account
+// at m/purpose'/coin_type'/accountIdx', using SLIP-132 version bytes
+// matching the address type and the wallet's network (xpub/ypub/zpub on mainnet,
+// tpub/upub/vpub on testnet, regtest and signet).
+func (w *HDWallet) BitcoinAccountXPub(addrType BitcoinAddressType, accountIdx int) (string, error) {
+	return w.bitcoinAccountExtendedKey(addrType, accountIdx, false)
+}
+
+// BitcoinAccountXPrv returns the serialized extended private key of the account
+// at m/purpose'/coin_type'/accountIdx', using SLIP-132 version bytes
+// matching the address type and the wallet's network (xprv/yprv/zprv on mainnet,
+// tprv/uprv/vprv on testnet, regtest and signet).
+func (w *HDWallet) BitcoinAccountXPrv(addrType BitcoinAddressType, accountIdx int) (string, error) {
+	return w.bitcoinAccountExtendedKey(addrType, accountIdx, true)
+}
+
+func (w *HDWallet) bitcoinAccountExtendedKey(addrType BitcoinAddressType, accountIdx int, private bool) (string, error) {
+	if !addrType.valid() {
+		return "", errors.Errorf("unsupported bitcoin address type %d", uint32(addrType))
+	}
+
+	if accountIdx < 0 || isHardenedIdx(accountIdx) {
+		return "", errors.Errorf("invalid bitcoin account index %d", accountIdx)
+	}
+
+	var accountKey *hdkeychain.ExtendedKey
+
+	err := w.vault.withMaster(func(masterKey *hdkeychain.ExtendedKey) (err error) {
+		accountKey, err = deriveExtendedKey(masterKey, bitcoinAccountPath(addrType, w.netParams, accountIdx), w.legacyDerivation)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	defer accountKey.Zero()
+
+	return serializeSLIP132(accountKey, addrType, w.netParams, private)
+}
+
+func bitcoinAccountPath(addrType BitcoinAddressType, netParams *chaincfg.Params, accountIdx int) accounts.DerivationPath {
+	return accounts.DerivationPath{
+		hdkeychain.HardenedKeyStart + addrType.Purpose(),
+		hdkeychain.HardenedKeyStart + netParams.HDCoinType,
+		hdkeychain.HardenedKeyStart + uint32(accountIdx),
+	}
+}
+
+func bitcoinAddressFromPubKey(addrType BitcoinAddressType, pubKey *btcec.PublicKey, netParams *chaincfg.Params) (btcutil.Address, error) {
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+	switch addrType {
+	case BitcoinP2PKH:
+		return btcutil.NewAddressPubKeyHash(pubKeyHash, netParams)
+	case BitcoinP2SHP2WPKH:
+		witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, netParams)
+		if err != nil {
+			return nil, err
+		}
+
+		redeemScript, err := txscript.PayToAddrScript(witnessAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		return btcutil.NewAddressScriptHash(redeemScript, netParams)
+	case BitcoinP2WPKH:
+		return btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, netParams)
+	case BitcoinP2TR:
+		outputKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+		return btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), netParams)
+	default:
+		return nil, errors.Errorf("unsupported bitcoin address type %d", uint32(addrType))
+	}
+}
+
+func (a BitcoinAddress) Address() btcutil.Address {
+	return a.address
+}
+
+// String returns the encoded form of the address.
+func (a BitcoinAddress) String() string {
+	return a.address.EncodeAddress()
+}
+
+func (a BitcoinAddress) AddressType() BitcoinAddressType {
+	return a.addressType
+}
+
+// PrivateKey returns a copy of the address' private key,
+// or ErrLocked while the address' wallet is locked.
+func (a BitcoinAddress) PrivateKey() (*btcec.PrivateKey, error) {
+	var privKey *btcec.PrivateKey
+
+	err := a.vault.withPrivateKey(a.privateKey, func(key *ecdsa.PrivateKey) error {
+		d := key.D.FillBytes(make([]byte, privateKeyLen))
+		defer wipeBytes(d)
+
+		privKey, _ = btcec.PrivKeyFromBytes(d)
+
+		return nil
+	})
+
+	return privKey, err
+}
+
+// WIF returns the private key in Wallet Import Format,
+// for use with the address' network and a compressed public key,
+// or ErrLocked while the address' wallet is locked.
+func (a BitcoinAddress) WIF() (string, error) {
+	privKey, err := a.PrivateKey()
+	if err != nil {
+		return "", err
+	}
+
+	defer privKey.Zero()
+
+	wif, err := btcutil.NewWIF(privKey, a.netParams, true)
+	if err != nil {
+		return "", err
+	}
+
+	return wif.String(), nil
+}
+
+func (a BitcoinAddress) PublicKey() *btcec.PublicKey {
+	return a.publicKey
+}
+
+// PublicKeyHex returns the hex-encoded compressed public key.
+func (a BitcoinAddress) PublicKeyHex() string {
+	return common.Bytes2Hex(a.PublicKeyBytes())
+}
+
+// PublicKeyBytes returns the compressed public key.
+func (a BitcoinAddress) PublicKeyBytes() []byte {
+	return a.publicKey.SerializeCompressed()
+}
+
+func (a BitcoinAddress) AccountIndex() int {
+	return int(a.derivationPath[2] - hdkeychain.HardenedKeyStart)
+}
+
+func (a BitcoinAddress) Change() bool {
+	return a.derivationPath[3] == bitcoinChangeChain
+}
+
+func (a BitcoinAddress) AddressIndex() int {
+	return int(a.derivationPath[4])
+}
+
+func (a BitcoinAddress) DerivationPath() string {
+	return a.derivationPath.String()
+}
@@ -0,0 +1,229 @@
+package hdwallet_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+func TestHDWallet_DeriveBitcoinAddress(t *testing.T) {
+	type args struct {
+		addrType   hdwallet.BitcoinAddressType
+		accountIdx int
+		change     bool
+		addressIdx int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     string
+		wantPath string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			"bip44 receive 0",
+			args{hdwallet.BitcoinP2PKH, 0, false, 0},
+			"1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+			"m/44'/0'/0'/0/0",
+			assert.NoError,
+		},
+		{
+			"bip49 receive 0",
+			args{hdwallet.BitcoinP2SHP2WPKH, 0, false, 0},
+			"37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf",
+			"m/49'/0'/0'/0/0",
+			assert.NoError,
+		},
+		{
+			"bip84 receive 0",
+			args{hdwallet.BitcoinP2WPKH, 0, false, 0},
+			"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+			"m/84'/0'/0'/0/0",
+			assert.NoError,
+		},
+		{
+			"bip84 change 0",
+			args{hdwallet.BitcoinP2WPKH, 0, true, 0},
+			"bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el",
+			"m/84'/0'/0'/1/0",
+			assert.NoError,
+		},
+		{
+			"bip86 receive 0",
+			args{hdwallet.BitcoinP2TR, 0, false, 0},
+			"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+			"m/86'/0'/0'/0/0",
+			assert.NoError,
+		},
+		{
+			"unsupported address type",
+			args{hdwallet.BitcoinAddressType(45), 0, false, 0},
+			"",
+			"",
+			assert.Error,
+		},
+		{
+			"negative address index",
+			args{hdwallet.BitcoinP2WPKH, 0, false, -1},
+			"",
+			"",
+			assert.Error,
+		},
+	}
+
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := w.DeriveBitcoinAddress(tt.args.addrType, tt.args.accountIdx, tt.args.change, tt.args.addressIdx)
+			if !tt.wantErr(t, err, fmt.Sprintf("DeriveBitcoinAddress(%v)", tt.args)) || err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, tt.wantPath, got.DerivationPath())
+			assert.Equal(t, tt.args.change, got.Change())
+			assert.Equal(t, tt.args.addressIdx, got.AddressIndex())
+		})
+	}
+}
+
+func TestHDWallet_DeriveBitcoinAddress_Cached(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	addr, err := w.DeriveBitcoinAddress(hdwallet.BitcoinP2WPKH, 0, false, 0)
+	require.NoError(t, err)
+
+	// deriving the same path again reuses the address and its private key
+	for i := 0; i < 3; i++ {
+		again, err := w.DeriveBitcoinAddress(hdwallet.BitcoinP2WPKH, 0, false, 0)
+		require.NoError(t, err)
+		assert.Same(t, addr, again)
+	}
+
+	others := []struct {
+		addrType   hdwallet.BitcoinAddressType
+		accountIdx int
+		change     bool
+		addressIdx int
+	}{
+		{hdwallet.BitcoinP2TR, 0, false, 0},
+		{hdwallet.BitcoinP2WPKH, 1, false, 0},
+		{hdwallet.BitcoinP2WPKH, 0, true, 0},
+		{hdwallet.BitcoinP2WPKH, 0, false, 1},
+	}
+
+	for _, other := range others {
+		got, err := w.DeriveBitcoinAddress(other.addrType, other.accountIdx, other.change, other.addressIdx)
+		require.NoError(t, err)
+		assert.NotSame(t, addr, got)
+		assert.NotEqual(t, addr.String(), got.String())
+	}
+}
+
+func TestHDWallet_BitcoinAccountXPub(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  *chaincfg.Params
+		addrType hdwallet.BitcoinAddressType
+		want     string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			"mainnet bip49",
+			&chaincfg.MainNetParams,
+			hdwallet.BitcoinP2SHP2WPKH,
+			"ypub6Ww3ibxVfGzLrAH1PNcjyAWenMTbbAosGNB6VvmSEgytSER9azLDWCxoJwW7Ke7icmizBMXrzBx9979FfaHxHcrArf3zbeJJJUZPf663zsP",
+			assert.NoError,
+		},
+		{
+			"mainnet bip84",
+			&chaincfg.MainNetParams,
+			hdwallet.BitcoinP2WPKH,
+			"zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs",
+			assert.NoError,
+		},
+		{
+			"testnet bip84",
+			&chaincfg.TestNet3Params,
+			hdwallet.BitcoinP2WPKH,
+			"vpub",
+			assert.NoError,
+		},
+		{
+			"regtest bip49",
+			&chaincfg.RegressionNetParams,
+			hdwallet.BitcoinP2SHP2WPKH,
+			"upub",
+			assert.NoError,
+		},
+		{
+			"signet bip44",
+			&chaincfg.SigNetParams,
+			hdwallet.BitcoinP2PKH,
+			"tpub",
+			assert.NoError,
+		},
+		{
+			"simnet bip84",
+			&chaincfg.SimNetParams,
+			hdwallet.BitcoinP2WPKH,
+			"",
+			assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero), hdwallet.WithNetwork(tt.network))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := w.BitcoinAccountXPub(tt.addrType, 0)
+			if !tt.wantErr(t, err, fmt.Sprintf("BitcoinAccountXPub(%v, 0)", tt.addrType)) || err != nil {
+				return
+			}
+
+			assert.True(t, strings.HasPrefix(got, tt.want), "expected %s to start with %s", got, tt.want)
+		})
+	}
+}
+
+func TestHDWallet_WithNetwork(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero), hdwallet.WithNetwork(&chaincfg.TestNet3Params))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, strings.HasPrefix(w.MasterKey().String(), "tprv"))
+
+	addr, err := w.DeriveBitcoinAddress(hdwallet.BitcoinP2WPKH, 0, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "tb1q6rz28mcfaxtmd6v789l9rrlrusdprr9pqcpvkl", addr.String())
+	assert.Equal(t, "m/84'/1'/0'/0/0", addr.DerivationPath())
+
+	w, err = hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero), hdwallet.WithNetwork(&chaincfg.RegressionNetParams))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err = w.DeriveBitcoinAddress(hdwallet.BitcoinP2TR, 0, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, strings.HasPrefix(addr.String(), "bcrt1p"))
+}
@@ -0,0 +1,269 @@
+package clef
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"mime"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/policy"
+)
+
+// SignTransactionResult is the result of account_signTransaction.
+type SignTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// api implements the account_ namespace.
+type api struct {
+	wallet  *hdwallet.HDWallet
+	approve ApprovalFunc
+	chainID *big.Int
+	policy  *policy.Engine
+}
+
+// Version returns the version of the external API.
+func (a *api) Version(_ context.Context) (string, error) {
+	return ExternalAPIVersion, nil
+}
+
+// List returns the wallet's derived and imported addresses.
+func (a *api) List(ctx context.Context) ([]common.Address, error) {
+	if err := a.approve.approve(ctx, &Request{Method: MethodList}); err != nil {
+		return nil, err
+	}
+
+	walletAddrs := a.wallet.Accounts()
+
+	addrs := make([]common.Address, len(walletAddrs))
+	for i, addr := range walletAddrs {
+		addrs[i] = addr.Address()
+	}
+
+	return addrs, nil
+}
+
+// SignTransaction signs a transaction from one of the wallet's addresses.
+// If the transaction has no chain ID, the server's chain ID is used,
+// and transactions for other chains are refused.
+func (a *api) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, _ *string) (_ *SignTransactionResult, err error) {
+	addr, err := a.account(args.From.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	if args.ChainID == nil {
+		args.ChainID = (*hexutil.Big)(a.chainID)
+	} else if args.ChainID.ToInt().Cmp(a.chainID) != 0 {
+		return nil, errors.Errorf("chain id %v doesn't match the signer's chain id %v", args.ChainID.ToInt(), a.chainID)
+	}
+
+	if args.MaxFeePerGas == nil && args.GasPrice == nil {
+		return nil, errors.New("gasPrice or maxFeePerGas must be specified")
+	}
+
+	if args.MaxFeePerGas != nil && args.MaxPriorityFeePerGas == nil {
+		return nil, errors.New("maxPriorityFeePerGas must be specified with maxFeePerGas")
+	}
+
+	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
+		return nil, errors.New("data and input must match when both are specified")
+	}
+
+	tx := args.ToTransaction()
+	chainID := (*big.Int)(args.ChainID)
+
+	policyReq := &policy.Request{From: addr.Address(), ChainID: chainID, Tx: tx}
+	if err = a.checkPolicy(ctx, policyReq); err != nil {
+		return nil, err
+	}
+
+	defer func() { a.policyDone(policyReq, err) }()
+
+	err = a.approve.approve(ctx, &Request{
+		Method:      MethodSignTransaction,
+		From:        addr.Address(),
+		Transaction: tx,
+		ChainID:     chainID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := addr.SignTx(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding signed transaction")
+	}
+
+	return &SignTransactionResult{Raw: raw, Tx: signed}, nil
+}
+
+// SignData signs data of the passed content type, which must be either
+// text/plain (EIP-191 personal messages) or data/validator (EIP-191 version 0).
+// Signatures use V = 27 or 28.
+func (a *api) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error) {
+	account, err := a.account(addr.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid content type %q", contentType)
+	}
+
+	var rawData []byte
+
+	switch mediaType {
+	case accounts.MimetypeTextPlain:
+		hexData, ok := data.(string)
+		if !ok {
+			return nil, errors.Errorf("input for %s must be a hex-encoded string", mediaType)
+		}
+
+		text, err := hexutil.Decode(hexData)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid input for %s", mediaType)
+		}
+
+		rawData = []byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(text), text))
+	case accounts.MimetypeDataWithValidator:
+		validator, message, err := validatorData(data)
+		if err != nil {
+			return nil, err
+		}
+
+		rawData = append(append([]byte{0x19, 0x00}, validator.Bytes()...), message...)
+	default:
+		return nil, errors.Errorf("unsupported content type %q", mediaType)
+	}
+
+	return a.signData(ctx, &Request{
+		Method:      MethodSignData,
+		From:        account.Address(),
+		ContentType: mediaType,
+		Data:        rawData,
+		Hash:        crypto.Keccak256(rawData),
+	}, account)
+}
+
+// SignTypedData signs EIP-712 typed data, using V = 27 or 28.
+func (a *api) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, typedData apitypes.TypedData) (hexutil.Bytes, error) {
+	account, err := a.account(addr.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, errors.Wrap(err, "error hashing typed data domain")
+	}
+
+	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, errors.Wrap(err, "error hashing typed data message")
+	}
+
+	rawData := append(append([]byte{0x19, 0x01}, domainSeparator...), typedDataHash...)
+
+	return a.signData(ctx, &Request{
+		Method:      MethodSignTypedData,
+		From:        account.Address(),
+		ContentType: apitypes.DataTyped.Mime,
+		Data:        rawData,
+		TypedData:   &typedData,
+		Hash:        crypto.Keccak256(rawData),
+	}, account)
+}
+
+func (a *api) signData(ctx context.Context, req *Request, signer hdwallet.Signer) (_ hexutil.Bytes, err error) {
+	policyReq := &policy.Request{From: req.From, Message: req.Data}
+	if err = a.checkPolicy(ctx, policyReq); err != nil {
+		return nil, err
+	}
+
+	defer func() { a.policyDone(policyReq, err) }()
+
+	if err = a.approve.approve(ctx, req); err != nil {
+		return nil, err
+	}
+
+	sig, err := signer.SignHash(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// transform V from 0/1 to 27/28, as Clef does.
+	sig[crypto.RecoveryIDOffset] += 27
+
+	return sig, nil
+}
+
+// checkPolicy checks req against the server's signing policy, if any.
+func (a *api) checkPolicy(ctx context.Context, req *policy.Request) error {
+	if a.policy == nil {
+		return nil
+	}
+
+	_, err := a.policy.Check(ctx, req)
+
+	return err
+}
+
+// policyDone reports whether a request allowed by checkPolicy was signed,
+// so that only signed requests count towards the policy's limits.
+func (a *api) policyDone(req *policy.Request, err error) {
+	if a.policy != nil {
+		a.policy.Done(req, err)
+	}
+}
+
+func (a *api) account(address common.Address) (*hdwallet.HDWalletAddress, error) {
+	addr, ok := a.wallet.FindByAddress(address)
+	if !ok {
+		return nil, errors.Errorf("unknown account %s", address)
+	}
+
+	return addr, nil
+}
+
+// validatorData decodes the {"address": ..., "message": ...} input of data/validator requests.
+func validatorData(data interface{}) (common.Address, []byte, error) {
+	raw, ok := data.(map[string]interface{})
+	if !ok {
+		return common.Address{}, nil, errors.New("validator input must be an object")
+	}
+
+	addr, ok := raw["address"].(string)
+	if !ok || !common.IsHexAddress(addr) {
+		return common.Address{}, nil, errors.New("validator input has an invalid address")
+	}
+
+	msg, ok := raw["message"].(string)
+	if !ok {
+		return common.Address{}, nil, errors.New("validator input has no message")
+	}
+
+	message, err := hexutil.Decode(msg)
+	if err != nil {
+		return common.Address{}, nil, errors.Wrap(err, "validator input has an invalid message")
+	}
+
+	return common.HexToAddress(addr), message, nil
+}
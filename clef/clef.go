@@ -0,0 +1,69 @@
+// Package clef serves an hdwallet.HDWallet over Clef's external signer JSON-RPC API
+// (see https://geth.ethereum.org/docs/tools/clef/apis), so that geth's --signer flag
+// and other Clef clients can use the wallet's keys unchanged.
+//
+// Every request other than account_version must be approved by an ApprovalFunc.
+package clef
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/pkg/errors"
+)
+
+// ExternalAPIVersion is the version of Clef's external API implemented by Server.
+const ExternalAPIVersion string = "6.1.0"
+
+// Methods served by Server.
+const (
+	MethodList            string = "account_list"
+	MethodSignTransaction string = "account_signTransaction"
+	MethodSignData        string = "account_signData"
+	MethodSignTypedData   string = "account_signTypedData"
+)
+
+// ErrRequestDenied is returned to clients when an ApprovalFunc rejects their request.
+var ErrRequestDenied = errors.New("request denied")
+
+// Request describes a request awaiting approval.
+type Request struct {
+	// Method is the JSON-RPC method being called.
+	Method string
+	// From is the address asked to sign, which is empty for account_list.
+	From common.Address
+	// Transaction is the unsigned transaction for account_signTransaction.
+	Transaction *types.Transaction
+	// ChainID is the chain ID the transaction is signed for.
+	ChainID *big.Int
+	// ContentType is the content type of account_signData requests.
+	ContentType string
+	// Data is the raw data being signed for account_signData and account_signTypedData,
+	// before hashing.
+	Data []byte
+	// TypedData is the EIP-712 data for account_signTypedData.
+	TypedData *apitypes.TypedData
+	// Hash is the hash which will be signed for account_signData and account_signTypedData.
+	Hash []byte
+}
+
+// ApprovalFunc decides whether a request is allowed,
+// for example by prompting an operator or applying a policy.
+// Requests are only served if it returns true and a nil error.
+type ApprovalFunc func(ctx context.Context, req *Request) (bool, error)
+
+func (f ApprovalFunc) approve(ctx context.Context, req *Request) error {
+	ok, err := f(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "error approving request")
+	}
+
+	if !ok {
+		return ErrRequestDenied
+	}
+
+	return nil
+}
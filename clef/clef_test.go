@@ -0,0 +1,422 @@
+package clef_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/clef"
+	"github.com/jalavosus/hdwallet-go/policy"
+)
+
+const testMnemonic string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// approvals records every request and approves them unless deny is set.
+type approvals struct {
+	mu       sync.Mutex
+	requests []*clef.Request
+	deny     bool
+}
+
+func (a *approvals) approve(_ context.Context, req *clef.Request) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.requests = append(a.requests, req)
+
+	return !a.deny, nil
+}
+
+func (a *approvals) setDeny(deny bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.deny = deny
+}
+
+func (a *approvals) methods() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	methods := make([]string, len(a.requests))
+	for i, req := range a.requests {
+		methods[i] = req.Method
+	}
+
+	return methods
+}
+
+func (a *approvals) last() *clef.Request {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.requests[len(a.requests)-1]
+}
+
+func newTestServer(t *testing.T) (*hdwallet.HDWallet, *clef.Server, *approvals) {
+	t.Helper()
+
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	_, err = w.DeriveRange(0, 3)
+	require.NoError(t, err)
+
+	approver := new(approvals)
+
+	server, err := clef.NewServer(w, approver.approve, clef.WithChainID(big.NewInt(5)))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = server.Close() })
+
+	return w, server, approver
+}
+
+func TestNewServer(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	_, err = clef.NewServer(w, nil)
+	assert.Error(t, err, "approval function is required")
+
+	_, err = clef.NewServer(nil, new(approvals).approve)
+	assert.Error(t, err, "wallet is required")
+
+	_, err = clef.NewServer(w, new(approvals).approve, clef.WithChainID(big.NewInt(0)))
+	assert.Error(t, err, "chain id must be positive")
+}
+
+// TestExternalSigner checks the server against go-ethereum's own Clef client,
+// which geth uses for --signer, over both HTTP and a Unix socket.
+func TestExternalSigner(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint func(*testing.T, *clef.Server) string
+	}{
+		{
+			"http",
+			func(t *testing.T, server *clef.Server) string {
+				httpServer := httptest.NewServer(server)
+				t.Cleanup(httpServer.Close)
+
+				return httpServer.URL
+			},
+		},
+		{
+			"unix",
+			func(t *testing.T, server *clef.Server) string {
+				path := filepath.Join(t.TempDir(), "clef.ipc")
+
+				go func() { _ = server.ListenAndServeUnix(path) }()
+
+				require.Eventually(t, func() bool {
+					client, err := rpc.Dial(path)
+					if err != nil {
+						return false
+					}
+
+					client.Close()
+
+					return true
+				}, 5*time.Second, 10*time.Millisecond)
+
+				return path
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, server, approver := newTestServer(t)
+
+			signer, err := external.NewExternalSigner(tt.endpoint(t, server))
+			require.NoError(t, err)
+
+			var want []accounts.Account
+			for _, addr := range w.Accounts() {
+				want = append(want, accounts.Account{Address: addr.Address(), URL: signer.URL()})
+			}
+
+			assert.Equal(t, want, signer.Accounts())
+
+			from := want[1]
+			to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+			txs := []*types.Transaction{
+				types.NewTx(&types.LegacyTx{Nonce: 1, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1e9)}),
+				types.NewTx(&types.DynamicFeeTx{
+					ChainID: big.NewInt(5), Nonce: 2, To: &to, Value: big.NewInt(2),
+					Gas: 21000, GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9),
+				}),
+			}
+
+			for _, tx := range txs {
+				signed, err := signer.SignTx(from, tx, nil)
+				require.NoError(t, err)
+
+				sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(5)), signed)
+				require.NoError(t, err)
+
+				assert.Equal(t, from.Address, sender)
+				assert.Equal(t, tx.Nonce(), signed.Nonce())
+			}
+
+			_, err = signer.SignTx(from, types.NewTx(&types.DynamicFeeTx{
+				ChainID: big.NewInt(1), Nonce: 3, To: &to, Value: big.NewInt(3),
+				Gas: 21000, GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9),
+			}), nil)
+			assert.ErrorContains(t, err, "doesn't match the signer's chain id")
+
+			text := []byte("hello world")
+
+			sig, err := signer.SignText(from, text)
+			require.NoError(t, err)
+
+			pubKey, err := crypto.SigToPub(accounts.TextHash(text), sig)
+			require.NoError(t, err)
+			assert.Equal(t, from.Address, crypto.PubkeyToAddress(*pubKey))
+
+			approver.setDeny(true)
+
+			_, err = signer.SignText(from, text)
+			assert.ErrorContains(t, err, clef.ErrRequestDenied.Error())
+
+			_, err = signer.SignText(accounts.Account{Address: to}, text)
+			assert.ErrorContains(t, err, "unknown account")
+
+			assert.Equal(t, []string{
+				clef.MethodList,
+				clef.MethodSignTransaction,
+				clef.MethodSignTransaction,
+				clef.MethodSignData,
+				clef.MethodSignData,
+			}, approver.methods())
+		})
+	}
+}
+
+func TestSignDataAndTypedData(t *testing.T) {
+	w, server, approver := newTestServer(t)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client, err := rpc.Dial(httpServer.URL)
+	require.NoError(t, err)
+
+	t.Cleanup(client.Close)
+
+	from := w.Accounts()[0].Address()
+	validator := common.HexToAddress("0x000000000000000000000000000000000000bEEF")
+	message := []byte{0xde, 0xad}
+
+	var sig hexutil.Bytes
+	err = client.Call(&sig, clef.MethodSignData, accounts.MimetypeDataWithValidator, from, map[string]string{
+		"address": validator.Hex(),
+		"message": hexutil.Encode(message),
+	})
+	require.NoError(t, err)
+
+	wantHash := crypto.Keccak256(append(append([]byte{0x19, 0x00}, validator.Bytes()...), message...))
+	assert.Equal(t, from, recoverLegacyV(t, wantHash, sig))
+
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+			"Mail":         {{Name: "contents", Type: "string"}},
+		},
+		PrimaryType: "Mail",
+		Domain:      apitypes.TypedDataDomain{Name: "test", ChainId: math.NewHexOrDecimal256(5)},
+		Message:     apitypes.TypedDataMessage{"contents": "hello"},
+	}
+
+	err = client.Call(&sig, clef.MethodSignTypedData, from, typedData)
+	require.NoError(t, err)
+
+	last := approver.last()
+
+	assert.Equal(t, clef.MethodSignTypedData, last.Method)
+	assert.Equal(t, "hello", last.TypedData.Message["contents"])
+	assert.Equal(t, from, recoverLegacyV(t, last.Hash, sig))
+
+	err = client.Call(&sig, clef.MethodSignData, "application/x-unknown", from, "0x00")
+	assert.Error(t, err)
+}
+
+func recoverLegacyV(t *testing.T, hash []byte, sig []byte) common.Address {
+	t.Helper()
+
+	require.Len(t, sig, crypto.SignatureLength)
+	require.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset])
+
+	normalized := make([]byte, len(sig))
+	copy(normalized, sig)
+	normalized[crypto.RecoveryIDOffset] -= 27
+
+	pubKey, err := crypto.SigToPub(hash, normalized)
+	require.NoError(t, err)
+
+	return crypto.PubkeyToAddress(*pubKey)
+}
+
+func TestWithPolicy(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	_, err = w.DeriveRange(0, 1)
+	require.NoError(t, err)
+
+	cfg, err := policy.ParseConfig([]byte("recipients: {deny: [0x000000000000000000000000000000000000dEaD]}\napproval: {messages: true}"))
+	require.NoError(t, err)
+
+	engine, err := policy.NewEngine(cfg)
+	require.NoError(t, err)
+
+	approver := new(approvals)
+
+	server, err := clef.NewServer(w, approver.approve, clef.WithChainID(big.NewInt(5)), clef.WithPolicy(engine))
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	signer, err := external.NewExternalSigner(httpServer.URL)
+	require.NoError(t, err)
+
+	from := accounts.Account{Address: w.Accounts()[0].Address()}
+	allowed := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	denied := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	_, err = signer.SignTx(from, types.NewTx(&types.LegacyTx{To: &allowed, Gas: 21000, GasPrice: big.NewInt(1e9)}), nil)
+	assert.NoError(t, err)
+
+	_, err = signer.SignTx(from, types.NewTx(&types.LegacyTx{To: &denied, Gas: 21000, GasPrice: big.NewInt(1e9)}), nil)
+	assert.ErrorContains(t, err, policy.ErrDenied.Error())
+
+	// messages require approval, and the engine has no approval function
+	_, err = signer.SignText(from, []byte("hello world"))
+	assert.ErrorContains(t, err, policy.ErrDenied.Error())
+
+	// denied requests never reach the server's approval function
+	assert.Equal(t, []string{clef.MethodSignTransaction}, approver.methods())
+}
+
+func TestWithPolicy_ApprovalDenied(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	_, err = w.DeriveRange(0, 1)
+	require.NoError(t, err)
+
+	cfg, err := policy.ParseConfig([]byte("limits: [{rate_limit: {requests: 1, per: 1h}}]"))
+	require.NoError(t, err)
+
+	engine, err := policy.NewEngine(cfg)
+	require.NoError(t, err)
+
+	approver := new(approvals)
+
+	server, err := clef.NewServer(w, approver.approve, clef.WithChainID(big.NewInt(5)), clef.WithPolicy(engine))
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	signer, err := external.NewExternalSigner(httpServer.URL)
+	require.NoError(t, err)
+
+	from := accounts.Account{Address: w.Accounts()[0].Address()}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tx := types.NewTx(&types.LegacyTx{To: &to, Gas: 21000, GasPrice: big.NewInt(1e9)})
+
+	// requests refused by the operator don't count towards the rate limit
+	approver.setDeny(true)
+
+	for i := 0; i < 3; i++ {
+		_, err = signer.SignTx(from, tx, nil)
+		assert.Error(t, err)
+	}
+
+	approver.setDeny(false)
+
+	_, err = signer.SignTx(from, tx, nil)
+	assert.NoError(t, err)
+
+	_, err = signer.SignTx(from, tx, nil)
+	assert.ErrorContains(t, err, "rate limit")
+}
+
+func TestServer_HostCheck(t *testing.T) {
+	_, server, _ := newTestServer(t)
+
+	tests := []struct {
+		host       string
+		wantStatus int
+	}{
+		{"localhost:8550", http.StatusOK},
+		{"LOCALHOST", http.StatusOK},
+		{"127.0.0.1:8550", http.StatusOK},
+		{"[::1]:8550", http.StatusOK},
+		{"attacker.example:8550", http.StatusForbidden},
+		{"192.0.2.1", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			body := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"account_version"}`)
+
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			req.Host = tt.host
+			req.Header.Set("Content-Type", "application/json")
+
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestListenAndServeUnix_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket permissions aren't supported on windows")
+	}
+
+	_, server, _ := newTestServer(t)
+
+	path := filepath.Join(t.TempDir(), "clef.ipc")
+
+	go func() { _ = server.ListenAndServeUnix(path) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
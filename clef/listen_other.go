@@ -0,0 +1,24 @@
+//go:build !unix
+
+package clef
+
+import (
+	"net"
+	"os"
+)
+
+// listenUnix creates a Unix socket at path which is only accessible by the current user.
+// Platforms other than Unix have no umask, so the socket is restricted after creation.
+func listenUnix(path string) (net.Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package clef
+
+import (
+	"net"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes changes of the process' umask made by listenUnix.
+var umaskMu sync.Mutex
+
+// listenUnix creates a Unix socket at path which is only accessible by the current user.
+// The socket is created under a restrictive umask rather than restricted after creation,
+// so other users can never connect to it.
+func listenUnix(path string) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+
+	oldMask := syscall.Umask(0o177)
+	defer syscall.Umask(oldMask)
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,216 @@
+package clef
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/policy"
+)
+
+// Server serves a wallet over Clef's external signer API.
+type Server struct {
+	rpcServer *rpc.Server
+
+	mu          sync.Mutex
+	httpServers []*http.Server
+	listeners   []net.Listener
+}
+
+type serverOpts struct {
+	chainID *big.Int
+	policy  *policy.Engine
+}
+
+type funcServerOpt struct {
+	f func(*serverOpts)
+}
+
+func newFuncServerOpt(f func(*serverOpts)) *funcServerOpt {
+	return &funcServerOpt{f}
+}
+
+func (fo *funcServerOpt) apply(opts *serverOpts) {
+	fo.f(opts)
+}
+
+type ServerOpt interface {
+	apply(*serverOpts)
+}
+
+// WithChainID sets the chain ID used to sign transactions which don't specify one,
+// like Clef's --chainid flag. Transactions specifying another chain ID are refused.
+// Defaults to 1 (Ethereum mainnet).
+func WithChainID(chainID *big.Int) ServerOpt {
+	return newFuncServerOpt(func(opts *serverOpts) {
+		opts.chainID = chainID
+	})
+}
+
+// WithPolicy checks account_signTransaction, account_signData and account_signTypedData
+// requests against a signing policy before asking for approval.
+func WithPolicy(engine *policy.Engine) ServerOpt {
+	return newFuncServerOpt(func(opts *serverOpts) {
+		opts.policy = engine
+	})
+}
+
+func defaultServerOpts() *serverOpts {
+	return &serverOpts{
+		chainID: params.MainnetChainConfig.ChainID,
+	}
+}
+
+// NewServer returns a Server signing with the derived and imported addresses of wallet,
+// asking approve to allow every request.
+func NewServer(wallet *hdwallet.HDWallet, approve ApprovalFunc, opts ...ServerOpt) (*Server, error) {
+	if wallet == nil {
+		return nil, errors.New("wallet must not be nil")
+	}
+
+	if approve == nil {
+		return nil, errors.New("an approval function is required")
+	}
+
+	sOpts := defaultServerOpts()
+	for _, o := range opts {
+		o.apply(sOpts)
+	}
+
+	if sOpts.chainID == nil || sOpts.chainID.Sign() <= 0 {
+		return nil, errors.Errorf("invalid chain id %v", sOpts.chainID)
+	}
+
+	rpcServer := rpc.NewServer()
+
+	err := rpcServer.RegisterName("account", &api{
+		wallet:  wallet,
+		approve: approve,
+		chainID: sOpts.chainID,
+		policy:  sOpts.policy,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error registering account api")
+	}
+
+	return &Server{rpcServer: rpcServer}, nil
+}
+
+// ServeHTTP serves JSON-RPC requests over HTTP.
+// Requests whose Host header isn't localhost or a loopback address are refused,
+// so web pages can't reach the server through DNS rebinding.
+// Only use it behind listeners which aren't reachable from other hosts;
+// see ListenAndServeHTTP.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackHost(r.Host) {
+		http.Error(w, "invalid host specified", http.StatusForbidden)
+		return
+	}
+
+	s.rpcServer.ServeHTTP(w, r)
+}
+
+// isLoopbackHost reports whether host, which may include a port,
+// is localhost or a loopback address.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// ListenAndServeHTTP serves JSON-RPC requests over HTTP on addr,
+// which must be a loopback address such as "127.0.0.1:8550".
+// It blocks until the server is closed.
+func (s *Server) ListenAndServeHTTP(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid listen address %q", addr)
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return errors.Errorf("refusing to listen on non-loopback address %q", addr)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "error listening for http connections")
+	}
+
+	httpServer := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.mu.Lock()
+	s.httpServers = append(s.httpServers, httpServer)
+	s.mu.Unlock()
+
+	if err := httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ListenAndServeUnix serves JSON-RPC requests over a Unix socket created at path,
+// which is only accessible by the current user.
+// It blocks until the server is closed.
+func (s *Server) ListenAndServeUnix(path string) error {
+	l, err := listenUnix(path)
+	if err != nil {
+		return errors.Wrap(err, "error listening on unix socket")
+	}
+
+	s.mu.Lock()
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	if err := s.rpcServer.ServeListener(l); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Close stops serving requests on every listener.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+
+	for _, httpServer := range s.httpServers {
+		if err := httpServer.Shutdown(context.Background()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, l := range s.listeners {
+		if err := l.Close(); err != nil && !errors.Is(err, net.ErrClosed) && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	s.rpcServer.Stop()
+
+	s.httpServers, s.listeners = nil, nil
+
+	return firstErr
+}
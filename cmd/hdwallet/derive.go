@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+// defaultPathTemplate is the BIP44 derivation path template used by derive.
+const defaultPathTemplate = "m/44'/{coin}'/{account}'/0/{index}"
+
+// maxDeriveCount is the maximum number of addresses derive prints at once.
+const maxDeriveCount = 10_000
+
+type addressInfo struct {
+	Index      int    `json:"index"`
+	Path       string `json:"path"`
+	Address    string `json:"address"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+type deriveResult []addressInfo
+
+func (r deriveResult) table() ([]string, [][]string) {
+	header := []string{"INDEX", "PATH", "ADDRESS", "PUBLIC KEY"}
+
+	showPrivate := len(r) > 0 && r[0].PrivateKey != ""
+	if showPrivate {
+		header = append(header, "PRIVATE KEY")
+	}
+
+	rows := make([][]string, len(r))
+	for i, addr := range r {
+		rows[i] = []string{strconv.Itoa(addr.Index), addr.Path, addr.Address, addr.PublicKey}
+		if showPrivate {
+			rows[i] = append(rows[i], addr.PrivateKey)
+		}
+	}
+
+	return header, rows
+}
+
+// deriveRange describes the addresses derived by deriveAddresses.
+type deriveRange struct {
+	template    string
+	account     int
+	start, end  int
+	hardened    bool
+	showPrivate bool
+}
+
+// path expands the range's path template for the address at index.
+func (r deriveRange) path(coinType hdwallet.CoinType, index int) string {
+	idx := strconv.Itoa(index)
+	if r.hardened {
+		idx += "'"
+	}
+
+	return strings.NewReplacer(
+		"{coin}", strconv.FormatUint(uint64(coinType), 10),
+		"{account}", strconv.Itoa(r.account),
+		"{index}", idx,
+	).Replace(r.template)
+}
+
+func deriveAddresses(w *hdwallet.HDWallet, r deriveRange) ([]addressInfo, error) {
+	if !strings.Contains(r.template, "{index}") {
+		return nil, errors.Errorf("path template %q has no {index} placeholder", r.template)
+	}
+
+	// hardened indices are passed with -hardened, so every index must be below 2^31.
+	if r.account < 0 || r.account >= hdkeychain.HardenedKeyStart {
+		return nil, errors.Errorf("invalid account %d", r.account)
+	}
+
+	if r.start < 0 || r.end < r.start || r.end > hdkeychain.HardenedKeyStart {
+		return nil, errors.Errorf("invalid index range [%d, %d): indices must be below %d", r.start, r.end, hdkeychain.HardenedKeyStart)
+	}
+
+	if r.end-r.start > maxDeriveCount {
+		return nil, errors.Errorf("index range [%d, %d) exceeds the maximum of %d addresses", r.start, r.end, maxDeriveCount)
+	}
+
+	var addrs []addressInfo
+
+	for idx := r.start; idx < r.end; idx++ {
+		path := r.path(w.CoinType(), idx)
+
+		key, err := w.DeriveExtendedKey(path)
+		if err != nil {
+			return nil, err
+		}
+
+		privKey, err := key.ECPrivKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error deriving private key at %s", path)
+		}
+
+		ecdsaKey := privKey.ToECDSA()
+
+		info := addressInfo{
+			Index:     idx,
+			Path:      path,
+			Address:   crypto.PubkeyToAddress(ecdsaKey.PublicKey).Hex(),
+			PublicKey: hexString(crypto.FromECDSAPub(&ecdsaKey.PublicKey)[1:]),
+		}
+
+		if r.showPrivate {
+			info.PrivateKey = hexString(crypto.FromECDSA(ecdsaKey))
+		}
+
+		addrs = append(addrs, info)
+	}
+
+	return addrs, nil
+}
+
+func runDerive(args []string, env *cliEnv) error {
+	var (
+		rFlags restoreFlags
+		oFlags outputFlags
+		r      deriveRange
+	)
+
+	fs := newFlagSet("derive", env)
+	rFlags.register(fs)
+	oFlags.register(fs)
+	fs.StringVar(&r.template, "path", defaultPathTemplate, "derivation path template, with {coin}, {account} and {index} placeholders")
+	fs.IntVar(&r.account, "account", 0, "account index substituted for {account}")
+	fs.IntVar(&r.start, "start", 0, "first address index to derive")
+	fs.IntVar(&r.end, "end", 10, "address index to stop deriving at (exclusive), at most "+strconv.Itoa(maxDeriveCount)+" past -start")
+	fs.BoolVar(&r.hardened, "hardened", false, "derive hardened address indices")
+	fs.BoolVar(&r.showPrivate, "show-private", false, "also print private keys")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := oFlags.validate(); err != nil {
+		return err
+	}
+
+	w, err := rFlags.restore(env)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := deriveAddresses(w, r)
+	if err != nil {
+		return err
+	}
+
+	return oFlags.print(env.stdout, deriveResult(addrs))
+}
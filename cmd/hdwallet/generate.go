@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/jalavosus/hdwallet-go"
+)
+
+type generateResult struct {
+	Mnemonic          string `json:"mnemonic"`
+	Entropy           string `json:"entropy"`
+	MasterFingerprint string `json:"master_fingerprint"`
+	MasterXPub        string `json:"master_xpub"`
+	FirstAddress      string `json:"first_address"`
+	FirstAddressPath  string `json:"first_address_path"`
+}
+
+func (r generateResult) table() ([]string, [][]string) {
+	return nil, keyValues(
+		"Mnemonic", r.Mnemonic,
+		"Entropy", r.Entropy,
+		"Master fingerprint", r.MasterFingerprint,
+		"Master xpub", r.MasterXPub,
+		"First address", r.FirstAddress+" ("+r.FirstAddressPath+")",
+	)
+}
+
+func runGenerate(args []string, env *cliEnv) error {
+	var (
+		wFlags walletFlags
+		oFlags outputFlags
+		bits   int
+	)
+
+	fs := newFlagSet("generate", env)
+	wFlags.register(fs)
+	oFlags.register(fs)
+	fs.IntVar(&bits, "bits", hdwallet.Entropy256Bit, "entropy bits (128, 160, 192, 224 or 256)")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := oFlags.validate(); err != nil {
+		return err
+	}
+
+	opts, err := wFlags.opts()
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := wFlags.readPassphrase(newSecretReader(env))
+	if err != nil {
+		return err
+	}
+
+	w, err := hdwallet.NewHDWallet(append(opts, hdwallet.WithEntropyBits(bits), hdwallet.WithPassphrase(passphrase))...)
+	if err != nil {
+		return err
+	}
+
+	overview, err := walletOverview(w)
+	if err != nil {
+		return err
+	}
+
+	first, err := deriveAddresses(w, deriveRange{template: defaultPathTemplate, end: 1})
+	if err != nil {
+		return err
+	}
+
+	return oFlags.print(env.stdout, generateResult{
+		Mnemonic:          w.Mnemonic(),
+		Entropy:           hexString(w.Entropy()),
+		MasterFingerprint: overview.MasterFingerprint,
+		MasterXPub:        overview.MasterXPub,
+		FirstAddress:      first[0].Address,
+		FirstAddressPath:  first[0].Path,
+	})
+}
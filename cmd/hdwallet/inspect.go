@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+type inspectResult struct {
+	overview
+	AccountPath        string `json:"account_path"`
+	AccountFingerprint string `json:"account_fingerprint"`
+	AccountXPub        string `json:"account_xpub"`
+	ChainPath          string `json:"chain_path"`
+	ChainXPub          string `json:"chain_xpub"`
+}
+
+func (r inspectResult) table() ([]string, [][]string) {
+	_, rows := r.overview.table()
+
+	return nil, append(rows, keyValues(
+		"Account path", r.AccountPath,
+		"Account fingerprint", r.AccountFingerprint,
+		"Account xpub", r.AccountXPub,
+		"Chain path", r.ChainPath,
+		"Chain xpub", r.ChainXPub,
+	)...)
+}
+
+func runInspect(args []string, env *cliEnv) error {
+	var (
+		rFlags  restoreFlags
+		oFlags  outputFlags
+		account int
+	)
+
+	fs := newFlagSet("inspect", env)
+	rFlags.register(fs)
+	oFlags.register(fs)
+	fs.IntVar(&account, "account", 0, "BIP44 account to inspect")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := oFlags.validate(); err != nil {
+		return err
+	}
+
+	if account < 0 {
+		return errors.Errorf("invalid account %d", account)
+	}
+
+	w, err := rFlags.restore(env)
+	if err != nil {
+		return err
+	}
+
+	res := inspectResult{
+		AccountPath: fmt.Sprintf("m/44'/%d'/%d'", uint32(w.CoinType()), account),
+	}
+
+	ov, err := walletOverview(w)
+	if err != nil {
+		return err
+	}
+
+	res.overview = *ov
+
+	res.ChainPath = res.AccountPath + "/0"
+
+	accountKey, err := w.DeriveExtendedKey(res.AccountPath)
+	if err != nil {
+		return err
+	}
+
+	res.AccountFingerprint, err = fingerprint(accountKey)
+	if err != nil {
+		return err
+	}
+
+	accountXPub, err := accountKey.Neuter()
+	if err != nil {
+		return errors.Wrap(err, "error computing account xpub")
+	}
+
+	res.AccountXPub = accountXPub.String()
+
+	chainKey, err := w.DeriveExtendedKey(res.ChainPath)
+	if err != nil {
+		return err
+	}
+
+	chainXPub, err := chainKey.Neuter()
+	if err != nil {
+		return errors.Wrap(err, "error computing chain xpub")
+	}
+
+	res.ChainXPub = chainXPub.String()
+
+	return oFlags.print(env.stdout, res)
+}
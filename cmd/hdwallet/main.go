@@ -0,0 +1,113 @@
+// Command hdwallet generates, restores and inspects BIP32/BIP39/BIP44 HD wallets.
+//
+// Usage:
+//
+//	hdwallet <command> [flags]
+//
+// Commands:
+//
+//	generate  generate a new mnemonic
+//	restore   restore a wallet from a mnemonic, entropy or xprv and print its overview
+//	derive    derive a range of addresses
+//	inspect   print a wallet's fingerprints and extended public keys
+//	search    search a mnemonic's addresses for a target address
+//
+// Secrets (mnemonics, entropy, extended private keys and passphrases) are never
+// read from the command line: they're read from a no-echo prompt when stdin is a terminal,
+// or one per line from stdin otherwise.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, env *cliEnv) error
+}
+
+// cliEnv holds a command's input and output streams.
+type cliEnv struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands = []command{
+	{"generate", "generate a new mnemonic", runGenerate},
+	{"restore", "restore a wallet from a mnemonic, entropy or xprv and print its overview", runRestore},
+	{"derive", "derive a range of addresses", runDerive},
+	{"inspect", "print a wallet's fingerprints and extended public keys", runInspect},
+	{"search", "search a mnemonic's addresses for a target address", runSearch},
+}
+
+func main() {
+	if err := run(os.Args[1:], &cliEnv{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "hdwallet:", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+func run(args []string, env *cliEnv) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(env.stderr)
+		return flag.ErrHelp
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], env)
+		}
+	}
+
+	usage(env.stderr)
+
+	return errors.Errorf("unknown command %q", args[0])
+}
+
+func usage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: hdwallet <command> [flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `Run "hdwallet <command> -h" for a command's flags.`)
+}
+
+func newFlagSet(name string, env *cliEnv) *flag.FlagSet {
+	fs := flag.NewFlagSet("hdwallet "+name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+
+	return fs
+}
+
+// parseFlags parses args, rejecting positional arguments so secrets
+// aren't accidentally passed on the command line.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return errors.Errorf(
+			"unexpected arguments %q: secrets are read from stdin or a prompt, not the command line",
+			strings.Join(fs.Args(), " "),
+		)
+	}
+
+	return nil
+}
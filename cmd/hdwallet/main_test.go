@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	testMnemonic string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	// testMasterXPrv is the master key of testMnemonic with the passphrase "TREZOR".
+	testMasterXPrv string = "xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF"
+)
+
+func runCLI(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	err := run(args, &cliEnv{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr})
+
+	return stdout.String(), err
+}
+
+func TestGenerate(t *testing.T) {
+	out, err := runCLI(t, "", "generate", "-bits", "128", "-output", "json")
+	require.NoError(t, err)
+
+	var res generateResult
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+
+	assert.Len(t, strings.Fields(res.Mnemonic), 12)
+	assert.True(t, bip39.IsMnemonicValid(res.Mnemonic))
+	assert.Len(t, res.Entropy, 32)
+
+	restored, err := runCLI(t, res.Mnemonic+"\n", "restore", "-count", "1", "-output", "json")
+	require.NoError(t, err)
+
+	var ov overview
+	require.NoError(t, json.Unmarshal([]byte(restored), &ov))
+
+	assert.Equal(t, res.MasterXPub, ov.MasterXPub)
+	assert.Equal(t, res.FirstAddress, ov.Addresses[0].Address)
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+	}{
+		{"mnemonic", testMnemonic + "\nTREZOR\n", []string{"-passphrase"}},
+		{"xprv", testMasterXPrv + "\n", []string{"-from", "xprv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runCLI(t, tt.stdin, append([]string{"restore", "-output", "json", "-count", "2"}, tt.args...)...)
+			require.NoError(t, err)
+
+			var ov overview
+			require.NoError(t, json.Unmarshal([]byte(out), &ov))
+
+			assert.Equal(t, "mainnet", ov.Network)
+			assert.Len(t, ov.Addresses, 2)
+			assert.Equal(t, "m/44'/60'/0'/0/1", ov.Addresses[1].Path)
+		})
+	}
+
+	_, err := runCLI(t, "00000000000000000000000000000000\n", "restore", "-from", "entropy")
+	assert.NoError(t, err)
+
+	_, err = runCLI(t, testMnemonic+"\n", "restore", "-coin-type", "4294967356")
+	assert.Error(t, err, "coin type out of range")
+
+	_, err = runCLI(t, "", "restore")
+	assert.Error(t, err, "missing mnemonic")
+
+	_, err = runCLI(t, "", "restore", testMnemonic)
+	assert.Error(t, err, "secrets must not be passed as arguments")
+}
+
+func TestRestore_XPrvNetwork(t *testing.T) {
+	masterKey, err := hdkeychain.NewKeyFromString(testMasterXPrv)
+	require.NoError(t, err)
+
+	testnetKey, err := masterKey.CloneWithVersion(chaincfg.TestNet3Params.HDPrivateKeyID[:])
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		key         string
+		args        []string
+		wantNetwork string
+		wantPrefix  string
+	}{
+		{"xprv", testMasterXPrv, nil, "mainnet", "xpub"},
+		{"tprv", testnetKey.String(), nil, "testnet3", "tpub"},
+		{"xprv on testnet", testMasterXPrv, []string{"-network", "testnet3"}, "testnet3", "tpub"},
+		{"tprv on mainnet", testnetKey.String(), []string{"-network", "mainnet"}, "mainnet", "xpub"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"restore", "-from", "xprv", "-output", "json", "-count", "1"}, tt.args...)
+
+			out, err := runCLI(t, tt.key+"\n", args...)
+			require.NoError(t, err)
+
+			var ov overview
+			require.NoError(t, json.Unmarshal([]byte(out), &ov))
+
+			assert.Equal(t, tt.wantNetwork, ov.Network)
+			assert.True(t, strings.HasPrefix(ov.MasterXPub, tt.wantPrefix), ov.MasterXPub)
+		})
+	}
+}
+
+func TestDerive(t *testing.T) {
+	out, err := runCLI(t, testMnemonic+"\n", "derive", "-start", "1", "-end", "3", "-output", "json")
+	require.NoError(t, err)
+
+	var addrs []addressInfo
+	require.NoError(t, json.Unmarshal([]byte(out), &addrs))
+
+	require.Len(t, addrs, 2)
+	assert.Equal(t, "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0", addrs[0].Address)
+	assert.Equal(t, "0xb6716976A3ebe8D39aCEB04372f22Ff8e6802D7A", addrs[1].Address)
+	assert.Empty(t, addrs[0].PrivateKey)
+
+	out, err = runCLI(t, testMnemonic+"\n", "derive", "-path", "m/44'/{coin}'/{account}'/{index}", "-account", "2", "-hardened", "-end", "1")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "m/44'/60'/2'/0'")
+	assert.Contains(t, out, "ADDRESS")
+
+	_, err = runCLI(t, testMnemonic+"\n", "derive", "-path", "m/44'/60'/0'/0")
+	assert.Error(t, err, "template without {index}")
+
+	out, err = runCLI(t, testMnemonic+"\n", "derive", "-hardened", "-start", "2147483647", "-end", "2147483648")
+	require.NoError(t, err)
+	assert.Contains(t, out, "m/44'/60'/0'/0/2147483647'")
+
+	invalidRanges := [][]string{
+		{"-start", "-1"},
+		{"-start", "5", "-end", "4"},
+		{"-start", "2147483648", "-end", "2147483649"},
+		{"-start", "0", "-end", "2147483649"},
+		{"-start", "0", "-end", "10001"},
+		{"-start", "0", "-end", "9223372036854775807"},
+		{"-account", "2147483648"},
+	}
+
+	for _, args := range invalidRanges {
+		_, err = runCLI(t, testMnemonic+"\n", append([]string{"derive"}, args...)...)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	out, err := runCLI(t, testMnemonic+"\n", "inspect", "-output", "json")
+	require.NoError(t, err)
+
+	var res inspectResult
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+
+	assert.Equal(t, "73c5da0a", res.MasterFingerprint)
+	assert.Equal(t, "m/44'/60'/0'", res.AccountPath)
+	assert.True(t, strings.HasPrefix(res.AccountXPub, "xpub"))
+	assert.True(t, strings.HasPrefix(res.ChainXPub, "xpub"))
+}
+
+func TestSearch(t *testing.T) {
+	out, err := runCLI(t, testMnemonic+"\n", "search", "-address", "0x51cA8ff9f1C0a99f88E86B8112eA3237F55374cA", "-max-index", "10", "-output", "json")
+	require.NoError(t, err)
+
+	var res searchResult
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+
+	assert.True(t, res.Found)
+	assert.Equal(t, "m/44'/60'/0'/0/4", res.Path)
+	assert.Equal(t, 0, res.Account)
+	assert.Equal(t, 4, res.Index)
+
+	out, err = runCLI(t, testMnemonic+"\n", "search", "-address", "0x78839F6054d7ed13918bAe0473BA31b1Ca9D7265", "-accounts", "2", "-max-index", "10", "-output", "json")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, `"account": 1`)
+	assert.Contains(t, out, `"index": 0`, "index 0 must not be omitted")
+
+	out, err = runCLI(t, testMnemonic+"\n", "search", "-address", "0x0000000000000000000000000000000000000001", "-max-index", "10")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "false")
+
+	_, err = runCLI(t, testMnemonic+"\n", "search", "-address", "nope")
+	assert.Error(t, err)
+}
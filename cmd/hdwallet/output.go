@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// result is a command's output, which can be printed as JSON or as a table.
+type result interface {
+	// table returns the table's header, which is empty for key/value tables, and rows.
+	table() ([]string, [][]string)
+}
+
+type outputFlags struct {
+	format string
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "output", formatTable, "output format (table, json)")
+}
+
+func (f *outputFlags) validate() error {
+	if f.format != formatTable && f.format != formatJSON {
+		return errors.Errorf("unknown output format %q", f.format)
+	}
+
+	return nil
+}
+
+func (f *outputFlags) print(out io.Writer, res result) error {
+	if f.format == formatJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return errors.Wrap(enc.Encode(res), "error encoding output")
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	header, rows := res.table()
+	if len(header) > 0 {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return errors.Wrap(tw.Flush(), "error writing output")
+}
+
+// keyValues builds the rows of a key/value table, skipping empty values.
+func keyValues(pairs ...string) [][]string {
+	rows := make([][]string, 0, len(pairs)/2)
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+
+		rows = append(rows, []string{pairs[i] + ":", pairs[i+1]})
+	}
+
+	return rows
+}
+
+func hexString(b []byte) string {
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+type overview struct {
+	Network           string        `json:"network"`
+	CoinType          uint32        `json:"coin_type"`
+	MasterFingerprint string        `json:"master_fingerprint"`
+	MasterXPub        string        `json:"master_xpub"`
+	Addresses         []addressInfo `json:"addresses,omitempty"`
+}
+
+func (o overview) table() ([]string, [][]string) {
+	rows := keyValues(
+		"Network", o.Network,
+		"Coin type", strconv.FormatUint(uint64(o.CoinType), 10),
+		"Master fingerprint", o.MasterFingerprint,
+		"Master xpub", o.MasterXPub,
+	)
+
+	for _, addr := range o.Addresses {
+		rows = append(rows, []string{addr.Path + ":", addr.Address})
+	}
+
+	return nil, rows
+}
+
+func walletOverview(w *hdwallet.HDWallet) (*overview, error) {
+	masterFingerprint, err := fingerprint(w.MasterKey())
+	if err != nil {
+		return nil, err
+	}
+
+	masterXPub, err := w.MasterKey().Neuter()
+	if err != nil {
+		return nil, errors.Wrap(err, "error computing master xpub")
+	}
+
+	return &overview{
+		Network:           w.Network().Name,
+		CoinType:          uint32(w.CoinType()),
+		MasterFingerprint: masterFingerprint,
+		MasterXPub:        masterXPub.String(),
+	}, nil
+}
+
+func runRestore(args []string, env *cliEnv) error {
+	var (
+		rFlags restoreFlags
+		oFlags outputFlags
+		count  int
+	)
+
+	fs := newFlagSet("restore", env)
+	rFlags.register(fs)
+	oFlags.register(fs)
+	fs.IntVar(&count, "count", 5, "number of addresses to print")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := oFlags.validate(); err != nil {
+		return err
+	}
+
+	w, err := rFlags.restore(env)
+	if err != nil {
+		return err
+	}
+
+	res, err := walletOverview(w)
+	if err != nil {
+		return err
+	}
+
+	res.Addresses, err = deriveAddresses(w, deriveRange{template: defaultPathTemplate, end: count})
+	if err != nil {
+		return err
+	}
+
+	return oFlags.print(env.stdout, res)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+type searchResult struct {
+	Address string `json:"address"`
+	Found   bool   `json:"found"`
+	Path    string `json:"path,omitempty"`
+	Account int    `json:"account"`
+	Index   int    `json:"index"`
+}
+
+func (r searchResult) table() ([]string, [][]string) {
+	rows := keyValues(
+		"Address", r.Address,
+		"Found", strconv.FormatBool(r.Found),
+	)
+
+	if r.Found {
+		rows = append(rows, keyValues(
+			"Path", r.Path,
+			"Account", strconv.Itoa(r.Account),
+			"Index", strconv.Itoa(r.Index),
+		)...)
+	}
+
+	return nil, rows
+}
+
+func runSearch(args []string, env *cliEnv) error {
+	var (
+		wFlags   walletFlags
+		oFlags   outputFlags
+		address  string
+		maxIndex int
+		accounts int
+	)
+
+	fs := newFlagSet("search", env)
+	wFlags.register(fs)
+	oFlags.register(fs)
+	fs.StringVar(&address, "address", "", "address to search for")
+	fs.IntVar(&maxIndex, "max-index", 1000, "number of address indices to search in each account")
+	fs.IntVar(&accounts, "accounts", 1, "number of accounts to search")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := oFlags.validate(); err != nil {
+		return err
+	}
+
+	if !common.IsHexAddress(address) {
+		return errors.Errorf("invalid address %q", address)
+	}
+
+	if wFlags.legacy {
+		return errors.New("search doesn't support legacy derivation")
+	}
+
+	if _, err := wFlags.opts(); err != nil {
+		return err
+	}
+
+	secrets := newSecretReader(env)
+
+	mnemonic, err := secrets.read(sourceMnemonic)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := wFlags.readPassphrase(secrets)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	target := common.HexToAddress(address)
+
+	found, ok, err := hdwallet.SearchMnemonicForAddress(
+		ctx,
+		strings.Join(strings.Fields(mnemonic), " "),
+		passphrase,
+		target,
+		hdwallet.WithSearchCoinType(hdwallet.CoinType(wFlags.coinType)),
+		hdwallet.WithSearchAccounts(0, accounts),
+		hdwallet.WithSearchIndices(0, maxIndex),
+	)
+	if err != nil {
+		return err
+	}
+
+	res := searchResult{Address: target.Hex(), Found: ok}
+	if ok {
+		res.Path = found.DerivationPath.String()
+		res.Account = found.AccountIndex
+		res.Index = found.AddressIndex
+	}
+
+	return oFlags.print(env.stdout, res)
+}
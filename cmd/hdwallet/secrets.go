@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// secretReader reads secrets from a no-echo terminal prompt if stdin is a terminal,
+// and one per line from stdin otherwise.
+type secretReader struct {
+	prompt   io.Writer
+	terminal bool
+	fd       int
+	lines    *bufio.Reader
+}
+
+func newSecretReader(env *cliEnv) *secretReader {
+	r := &secretReader{prompt: env.stderr}
+
+	if f, ok := env.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		r.terminal = true
+		r.fd = int(f.Fd())
+	} else {
+		r.lines = bufio.NewReader(env.stdin)
+	}
+
+	return r
+}
+
+// read reads a single secret, described by name.
+// Only line endings are stripped, since passphrases may start or end with spaces.
+func (r *secretReader) read(name string) (string, error) {
+	if r.terminal {
+		fmt.Fprintf(r.prompt, "Enter %s: ", name)
+
+		secret, err := term.ReadPassword(r.fd)
+		fmt.Fprintln(r.prompt)
+
+		if err != nil {
+			return "", errors.Wrapf(err, "error reading %s", name)
+		}
+
+		return string(secret), nil
+	}
+
+	line, err := r.lines.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", errors.Errorf("no %s on stdin", name)
+		}
+
+		return "", errors.Wrapf(err, "error reading %s", name)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
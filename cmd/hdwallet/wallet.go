@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+const (
+	sourceMnemonic = "mnemonic"
+	sourceEntropy  = "entropy"
+	sourceXPrv     = "xprv"
+)
+
+var languages = map[string][]string{
+	"english":             wordlists.English,
+	"chinese-simplified":  wordlists.ChineseSimplified,
+	"chinese-traditional": wordlists.ChineseTraditional,
+	"czech":               wordlists.Czech,
+	"french":              wordlists.French,
+	"italian":             wordlists.Italian,
+	"japanese":            wordlists.Japanese,
+	"korean":              wordlists.Korean,
+	"spanish":             wordlists.Spanish,
+}
+
+var networks = map[string]*chaincfg.Params{
+	"mainnet":  &chaincfg.MainNetParams,
+	"testnet3": &chaincfg.TestNet3Params,
+	"regtest":  &chaincfg.RegressionNetParams,
+	"signet":   &chaincfg.SigNetParams,
+	"simnet":   &chaincfg.SimNetParams,
+}
+
+// walletFlags are the flags shared by every command which operates on a wallet.
+type walletFlags struct {
+	language   string
+	network    string
+	coinType   uint
+	legacy     bool
+	passphrase bool
+}
+
+func (f *walletFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.language, "language", "english", "mnemonic language ("+strings.Join(sortedKeys(languages), ", ")+")")
+	fs.StringVar(&f.network, "network", "", "network used to serialize extended keys ("+strings.Join(sortedKeys(networks), ", ")+"); defaults to mainnet, or to the network of a restored xprv")
+	fs.UintVar(&f.coinType, "coin-type", uint(hdwallet.CoinTypeEthereum), "SLIP-44 coin type used in derivation paths")
+	fs.BoolVar(&f.legacy, "legacy", false, "use pre-fix btcutil derivation (btcsuite/btcutil#172)")
+	fs.BoolVar(&f.passphrase, "passphrase", false, "read a BIP39 passphrase after the wallet's secret")
+}
+
+// opts returns the wallet options described by the flags,
+// setting the BIP39 word list used by go-bip39.
+func (f *walletFlags) opts() ([]hdwallet.NewWalletOpt, error) {
+	wordList, ok := languages[f.language]
+	if !ok {
+		return nil, errors.Errorf("unknown language %q", f.language)
+	}
+
+	bip39.SetWordList(wordList)
+
+	netParams, err := f.netParams()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.coinType > math.MaxUint32 {
+		return nil, errors.Errorf("invalid coin type %d", f.coinType)
+	}
+
+	return []hdwallet.NewWalletOpt{
+		hdwallet.WithNetwork(netParams),
+		hdwallet.WithCoinType(hdwallet.CoinType(f.coinType)),
+		hdwallet.WithLegacyDerivation(f.legacy),
+	}, nil
+}
+
+// netParams returns the parameters of the network flag's network, defaulting to mainnet.
+func (f *walletFlags) netParams() (*chaincfg.Params, error) {
+	if f.network == "" {
+		return &chaincfg.MainNetParams, nil
+	}
+
+	netParams, ok := networks[f.network]
+	if !ok {
+		return nil, errors.Errorf("unknown network %q", f.network)
+	}
+
+	return netParams, nil
+}
+
+// readPassphrase reads a passphrase if the passphrase flag is set.
+func (f *walletFlags) readPassphrase(secrets *secretReader) (string, error) {
+	if !f.passphrase {
+		return "", nil
+	}
+
+	return secrets.read("passphrase")
+}
+
+// restoreFlags select the kind of secret a wallet is restored from.
+type restoreFlags struct {
+	walletFlags
+	from string
+}
+
+func (f *restoreFlags) register(fs *flag.FlagSet) {
+	f.walletFlags.register(fs)
+	fs.StringVar(&f.from, "from", sourceMnemonic, "kind of secret read from stdin or a prompt (mnemonic, entropy, xprv)")
+}
+
+func (f *restoreFlags) restore(env *cliEnv) (*hdwallet.HDWallet, error) {
+	opts, err := f.opts()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := newSecretReader(env)
+
+	secret, err := secrets.read(f.from)
+	if err != nil {
+		return nil, err
+	}
+
+	secret = strings.TrimSpace(secret)
+
+	switch f.from {
+	case sourceMnemonic:
+		opts = append(opts, hdwallet.WithMnemonic(strings.Join(strings.Fields(secret), " ")))
+	case sourceEntropy:
+		entropy, err := hdwallet.EntropyFromString(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, hdwallet.WithEntropy(entropy))
+	case sourceXPrv:
+		if f.passphrase {
+			return nil, errors.New("passphrases can't be used with extended private keys")
+		}
+
+		masterKey, err := hdwallet.ParseExtendedKey(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		netParams, err := f.xprvNetParams(masterKey)
+		if err != nil {
+			return nil, err
+		}
+
+		// serialize the wallet's keys for the network rather than the xprv's.
+		masterKey, err = masterKey.CloneWithVersion(netParams.HDPrivateKeyID[:])
+		if err != nil {
+			return nil, errors.Wrap(err, "error converting extended key")
+		}
+
+		opts = append(opts, hdwallet.WithMasterKey(masterKey), hdwallet.WithNetwork(netParams))
+	default:
+		return nil, errors.Errorf("unknown secret kind %q", f.from)
+	}
+
+	passphrase, err := f.readPassphrase(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, hdwallet.WithPassphrase(passphrase))
+
+	return hdwallet.NewHDWallet(opts...)
+}
+
+// xprvNetParams returns the network a restored xprv is used on: the network flag's
+// network if set, or else the network whose version bytes the xprv uses,
+// defaulting to mainnet for other versions such as SLIP-132 ones.
+func (f *restoreFlags) xprvNetParams(key *hdkeychain.ExtendedKey) (*chaincfg.Params, error) {
+	if f.network != "" {
+		return f.netParams()
+	}
+
+	// regtest and signet share testnet3's version bytes.
+	for _, name := range []string{"mainnet", "testnet3", "simnet"} {
+		if netParams := networks[name]; bytes.Equal(key.Version(), netParams.HDPrivateKeyID[:]) {
+			return netParams, nil
+		}
+	}
+
+	return &chaincfg.MainNetParams, nil
+}
+
+// fingerprint returns the hex-encoded BIP32 fingerprint of key.
+func fingerprint(key *hdkeychain.ExtendedKey) (string, error) {
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	return hexString(btcutil.Hash160(pubKey.SerializeCompressed())[:4]), nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
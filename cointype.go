@@ -0,0 +1,165 @@
+package hdwallet
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/pkg/errors"
+)
+
+// CoinType is a SLIP-44 registered coin type (see https://github.com/satoshilabs/slips/blob/master/slip-0044.md),
+// used as the second level of BIP44 derivation paths (m/44'/coin_type'/account'/change/index).
+type CoinType uint32
+
+const (
+	// CoinTypeBitcoin is the SLIP-44 coin type for Bitcoin.
+	CoinTypeBitcoin CoinType = 0
+	// CoinTypeTestnet is the SLIP-44 coin type shared by all testnets.
+	CoinTypeTestnet CoinType = 1
+	// CoinTypeEthereum is the SLIP-44 coin type for Ethereum, and is the default coin type
+	// used for derived addresses.
+	CoinTypeEthereum CoinType = 60
+	// CoinTypeEthereumClassic is the SLIP-44 coin type for Ethereum Classic.
+	CoinTypeEthereumClassic CoinType = 61
+	// CoinTypeRSK is the SLIP-44 coin type for RSK (Rootstock) mainnet.
+	CoinTypeRSK CoinType = 137
+	// CoinTypePOA is the SLIP-44 coin type for POA Network.
+	CoinTypePOA CoinType = 178
+	// CoinTypeTomoChain is the SLIP-44 coin type for TomoChain.
+	CoinTypeTomoChain CoinType = 889
+	// CoinTypeThunderCore is the SLIP-44 coin type for ThunderCore.
+	CoinTypeThunderCore CoinType = 1001
+	// CoinTypeRSKTestnet is the SLIP-44 coin type for RSK (Rootstock) testnet.
+	CoinTypeRSKTestnet CoinType = 37310
+)
+
+var coinTypeRegistry = struct {
+	sync.RWMutex
+	names map[CoinType]string
+}{
+	names: map[CoinType]string{
+		CoinTypeBitcoin:         "Bitcoin",
+		CoinTypeTestnet:         "Testnet",
+		CoinTypeEthereum:        "Ethereum",
+		CoinTypeEthereumClassic: "Ethereum Classic",
+		CoinTypeRSK:             "RSK",
+		CoinTypePOA:             "POA Network",
+		CoinTypeTomoChain:       "TomoChain",
+		CoinTypeThunderCore:     "ThunderCore",
+		CoinTypeRSKTestnet:      "RSK Testnet",
+	},
+}
+
+// RegisterCoinType registers a custom coin type under the passed name,
+// making it available via LookupCoinType.
+// An error is returned if the coin type or name is already registered.
+func RegisterCoinType(coinType CoinType, name string) error {
+	if isHardenedIdx(int(coinType)) {
+		return errors.Errorf("coin type %d is out of range", uint32(coinType))
+	}
+
+	if name == "" {
+		return errors.New("coin type name must not be empty")
+	}
+
+	coinTypeRegistry.Lock()
+	defer coinTypeRegistry.Unlock()
+
+	if existing, ok := coinTypeRegistry.names[coinType]; ok {
+		return errors.Errorf("coin type %d is already registered as %q", uint32(coinType), existing)
+	}
+
+	for existing, n := range coinTypeRegistry.names {
+		if n == name {
+			return errors.Errorf("coin type name %q is already registered for coin type %d", name, uint32(existing))
+		}
+	}
+
+	coinTypeRegistry.names[coinType] = name
+
+	return nil
+}
+
+// unregisterCoinType removes a coin type registered using RegisterCoinType.
+func unregisterCoinType(coinType CoinType) {
+	coinTypeRegistry.Lock()
+	defer coinTypeRegistry.Unlock()
+
+	delete(coinTypeRegistry.names, coinType)
+}
+
+// LookupCoinType returns the registered coin type with the passed name.
+func LookupCoinType(name string) (CoinType, bool) {
+	coinTypeRegistry.RLock()
+	defer coinTypeRegistry.RUnlock()
+
+	for coinType, n := range coinTypeRegistry.names {
+		if n == name {
+			return coinType, true
+		}
+	}
+
+	return 0, false
+}
+
+// Registered returns whether the coin type is known to the registry,
+// either as a built-in or via RegisterCoinType.
+func (c CoinType) Registered() bool {
+	coinTypeRegistry.RLock()
+	defer coinTypeRegistry.RUnlock()
+
+	_, ok := coinTypeRegistry.names[c]
+
+	return ok
+}
+
+func (c CoinType) String() string {
+	coinTypeRegistry.RLock()
+	defer coinTypeRegistry.RUnlock()
+
+	if name, ok := coinTypeRegistry.names[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("CoinType(%d)", uint32(c))
+}
+
+// ParseDerivationPath parses an absolute derivation path such as "m/44'/61'/0'/0/1",
+// or a path such as "1" relative to m/44'/coin_type'/0'/0, the chain addresses
+// are derived from using the coin type. It's like accounts.ParseDerivationPath,
+// which resolves relative paths using Ethereum's coin type only.
+func ParseDerivationPath(coinType CoinType, path string) (accounts.DerivationPath, error) {
+	if !coinType.Registered() {
+		return nil, errors.Errorf("unregistered coin type %d", uint32(coinType))
+	}
+
+	path = strings.TrimSpace(path)
+
+	if path == "m" {
+		return accounts.DerivationPath{}, nil
+	}
+
+	parsed, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid derivation path %q", path)
+	}
+
+	if strings.HasPrefix(path, "m/") {
+		return parsed, nil
+	}
+
+	// accounts.ParseDerivationPath resolves relative paths against m/44'/60'/0'/0.
+	relative := parsed[len(accounts.DefaultRootDerivationPath):]
+
+	root := accounts.DerivationPath{
+		hdkeychain.HardenedKeyStart + 44,
+		hdkeychain.HardenedKeyStart + uint32(coinType),
+		hdkeychain.HardenedKeyStart,
+		0,
+	}
+
+	return append(root, relative...), nil
+}
@@ -0,0 +1,4 @@
+package hdwallet
+
+// UnregisterCoinType lets external tests remove the coin types they register.
+var UnregisterCoinType = unregisterCoinType
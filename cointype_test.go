@@ -0,0 +1,135 @@
+package hdwallet_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+func TestRegisterCoinType(t *testing.T) {
+	const testCoinType hdwallet.CoinType = 0x7fff0001
+
+	tests := []struct {
+		name     string
+		coinType hdwallet.CoinType
+		coinName string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{"custom coin type", testCoinType, "Test Chain", assert.NoError},
+		{"already registered", hdwallet.CoinTypeEthereum, "Not Ethereum", assert.Error},
+		{"name already registered", testCoinType + 1, "Ethereum", assert.Error},
+		{"empty name", testCoinType + 1, "", assert.Error},
+		{"hardened coin type", hdwallet.CoinType(0x80000000), "Hardened", assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := hdwallet.RegisterCoinType(tt.coinType, tt.coinName)
+			if err == nil {
+				t.Cleanup(func() { hdwallet.UnregisterCoinType(tt.coinType) })
+			}
+
+			if !tt.wantErr(t, err, fmt.Sprintf("RegisterCoinType(%v, %v)", tt.coinType, tt.coinName)) || err != nil {
+				return
+			}
+
+			got, ok := hdwallet.LookupCoinType(tt.coinName)
+			assert.True(t, ok)
+			assert.Equal(t, tt.coinType, got)
+			assert.Equal(t, tt.coinName, tt.coinType.String())
+		})
+	}
+}
+
+func TestWithCoinType(t *testing.T) {
+	tests := []struct {
+		name     string
+		coinType hdwallet.CoinType
+		wantPath string
+		wantAddr string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			"default",
+			hdwallet.CoinTypeEthereum,
+			"m/44'/60'/0'/0/0",
+			"0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+			assert.NoError,
+		},
+		{
+			"ethereum classic",
+			hdwallet.CoinTypeEthereumClassic,
+			"m/44'/61'/0'/0/0",
+			"",
+			assert.NoError,
+		},
+		{
+			"rsk",
+			hdwallet.CoinTypeRSK,
+			"m/44'/137'/0'/0/0",
+			"",
+			assert.NoError,
+		},
+		{
+			"unregistered",
+			hdwallet.CoinType(123456),
+			"",
+			"",
+			assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero), hdwallet.WithCoinType(tt.coinType))
+			if !tt.wantErr(t, err, fmt.Sprintf("NewHDWallet(WithCoinType(%v))", tt.coinType)) || err != nil {
+				return
+			}
+
+			got, err := w.DeriveAddress()
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.coinType, got.CoinType())
+			assert.Equal(t, tt.wantPath, got.DerivationPath())
+
+			if tt.wantAddr != "" {
+				assert.Equal(t, tt.wantAddr, got.Address().String())
+			}
+
+			// relative paths resolve against the wallet's coin type
+			found, ok := w.FindByPath("0")
+			assert.True(t, ok)
+			assert.Same(t, got, found)
+		})
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		coinType hdwallet.CoinType
+		path     string
+		want     string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{"master", hdwallet.CoinTypeEthereum, "m", "m", assert.NoError},
+		{"absolute", hdwallet.CoinTypeEthereumClassic, "m/44'/60'/1'/0/2", "m/44'/60'/1'/0/2", assert.NoError},
+		{"relative ethereum", hdwallet.CoinTypeEthereum, "2", "m/44'/60'/0'/0/2", assert.NoError},
+		{"relative ethereum classic", hdwallet.CoinTypeEthereumClassic, "2", "m/44'/61'/0'/0/2", assert.NoError},
+		{"relative hardened", hdwallet.CoinTypeRSK, " 1/2' ", "m/44'/137'/0'/0/1/2'", assert.NoError},
+		{"empty", hdwallet.CoinTypeEthereum, "", "", assert.Error},
+		{"invalid", hdwallet.CoinTypeEthereum, "m/44'/x", "", assert.Error},
+		{"unregistered coin type", hdwallet.CoinType(123456), "2", "", assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hdwallet.ParseDerivationPath(tt.coinType, tt.path)
+			if !tt.wantErr(t, err, fmt.Sprintf("ParseDerivationPath(%v, %q)", tt.coinType, tt.path)) || err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
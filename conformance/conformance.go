@@ -0,0 +1,125 @@
+// Package conformance provides published BIP32, BIP39 and Ethereum address
+// test vectors as JSON fixtures, along with tests running them against hdwallet.
+//
+// Fixtures live under testdata/, one directory per kind of vector;
+// adding a vector is a matter of adding or editing a JSON file.
+package conformance
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed testdata
+var fixtures embed.FS
+
+const (
+	bip32Dir    = "testdata/bip32"
+	bip39Dir    = "testdata/bip39"
+	ethereumDir = "testdata/ethereum"
+)
+
+// BIP32Fixture is a set of BIP32 test vectors.
+type BIP32Fixture struct {
+	Source      string               `json:"source"`
+	Vectors     []BIP32Vector        `json:"vectors"`
+	InvalidKeys []InvalidExtendedKey `json:"invalid_keys"`
+}
+
+// BIP32Vector is a master seed along with the extended keys derived from it.
+type BIP32Vector struct {
+	Name   string       `json:"name"`
+	Seed   string       `json:"seed"`
+	Chains []BIP32Chain `json:"chains"`
+}
+
+// BIP32Chain is the pair of extended keys at a derivation path.
+type BIP32Chain struct {
+	Path   string `json:"path"`
+	ExtPub string `json:"ext_pub"`
+	ExtPrv string `json:"ext_prv"`
+}
+
+// InvalidExtendedKey is a serialized extended key which must be rejected.
+type InvalidExtendedKey struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// BIP39Fixture is a set of BIP39 test vectors.
+type BIP39Fixture struct {
+	Source           string        `json:"source"`
+	Passphrase       string        `json:"passphrase"`
+	Vectors          []BIP39Vector `json:"vectors"`
+	InvalidMnemonics []string      `json:"invalid_mnemonics"`
+}
+
+// BIP39Vector is hex-encoded entropy along with its mnemonic and
+// the hex-encoded seed derived using the fixture's passphrase.
+type BIP39Vector struct {
+	Entropy  string `json:"entropy"`
+	Mnemonic string `json:"mnemonic"`
+	Seed     string `json:"seed"`
+}
+
+// AddressFixture is a set of Ethereum addresses known to be derived
+// from a mnemonic by some wallet application.
+type AddressFixture struct {
+	Source     string          `json:"source"`
+	Mnemonic   string          `json:"mnemonic"`
+	Passphrase string          `json:"passphrase"`
+	Addresses  []AddressVector `json:"addresses"`
+}
+
+// AddressVector is the EIP-55 checksummed address at a derivation path.
+type AddressVector struct {
+	Path    string `json:"path"`
+	Address string `json:"address"`
+}
+
+// BIP32Fixtures returns every BIP32 fixture, ordered by file name.
+func BIP32Fixtures() ([]BIP32Fixture, error) {
+	return loadFixtures[BIP32Fixture](bip32Dir)
+}
+
+// BIP39Fixtures returns every BIP39 fixture, ordered by file name.
+func BIP39Fixtures() ([]BIP39Fixture, error) {
+	return loadFixtures[BIP39Fixture](bip39Dir)
+}
+
+// AddressFixtures returns every Ethereum address fixture, ordered by file name.
+func AddressFixtures() ([]AddressFixture, error) {
+	return loadFixtures[AddressFixture](ethereumDir)
+}
+
+func loadFixtures[T any](dir string) ([]T, error) {
+	names, err := fs.Glob(fixtures, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing fixtures in %s", dir)
+	}
+
+	sort.Strings(names)
+
+	loaded := make([]T, 0, len(names))
+
+	for _, name := range names {
+		data, err := fixtures.ReadFile(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading fixture %s", name)
+		}
+
+		var fixture T
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, errors.Wrapf(err, "error decoding fixture %s", name)
+		}
+
+		loaded = append(loaded, fixture)
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,126 @@
+package conformance_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/conformance"
+)
+
+func TestBIP32(t *testing.T) {
+	fixtures, err := conformance.BIP32Fixtures()
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, fixture := range fixtures {
+		for _, vector := range fixture.Vectors {
+			seed, err := hex.DecodeString(vector.Seed)
+			require.NoError(t, err, vector.Name)
+
+			wallet, err := hdwallet.NewHDWallet(hdwallet.WithSeed(seed))
+			require.NoError(t, err, vector.Name)
+
+			for _, chain := range vector.Chains {
+				t.Run(vector.Name+" "+chain.Path, func(t *testing.T) {
+					key, err := wallet.DeriveExtendedKey(chain.Path)
+					require.NoError(t, err)
+
+					assert.Equal(t, chain.ExtPrv, key.String())
+
+					pub, err := key.Neuter()
+					require.NoError(t, err)
+
+					assert.Equal(t, chain.ExtPub, pub.String())
+
+					for _, serialized := range []string{chain.ExtPrv, chain.ExtPub} {
+						parsed, err := hdwallet.ParseExtendedKey(serialized)
+						if assert.NoError(t, err) {
+							assert.Equal(t, serialized, parsed.String())
+						}
+					}
+				})
+			}
+		}
+
+		for _, invalid := range fixture.InvalidKeys {
+			t.Run("invalid "+invalid.Reason, func(t *testing.T) {
+				_, err := hdwallet.ParseExtendedKey(invalid.Key)
+				assert.Error(t, err, invalid.Key)
+			})
+		}
+	}
+}
+
+func TestBIP39(t *testing.T) {
+	fixtures, err := conformance.BIP39Fixtures()
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, fixture := range fixtures {
+		for _, vector := range fixture.Vectors {
+			t.Run(vector.Mnemonic, func(t *testing.T) {
+				entropy, err := hex.DecodeString(vector.Entropy)
+				require.NoError(t, err)
+
+				fromEntropy, err := hdwallet.NewHDWallet(
+					hdwallet.WithEntropy(entropy),
+					hdwallet.WithPassphrase(fixture.Passphrase),
+				)
+				require.NoError(t, err)
+
+				assert.Equal(t, vector.Mnemonic, fromEntropy.Mnemonic())
+				assert.Equal(t, vector.Seed, hex.EncodeToString(fromEntropy.Seed()))
+
+				fromMnemonic, err := hdwallet.NewHDWallet(
+					hdwallet.WithMnemonic(vector.Mnemonic),
+					hdwallet.WithPassphrase(fixture.Passphrase),
+				)
+				require.NoError(t, err)
+
+				assert.Equal(t, vector.Seed, hex.EncodeToString(fromMnemonic.Seed()))
+			})
+		}
+
+		for _, mnemonic := range fixture.InvalidMnemonics {
+			t.Run("invalid "+mnemonic, func(t *testing.T) {
+				_, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(mnemonic))
+				assert.Error(t, err)
+			})
+		}
+	}
+}
+
+func TestEthereumAddresses(t *testing.T) {
+	fixtures, err := conformance.AddressFixtures()
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, fixture := range fixtures {
+		wallet, err := hdwallet.NewHDWallet(
+			hdwallet.WithMnemonic(fixture.Mnemonic),
+			hdwallet.WithPassphrase(fixture.Passphrase),
+		)
+		require.NoError(t, err, fixture.Source)
+
+		for _, vector := range fixture.Addresses {
+			t.Run(fixture.Mnemonic+" "+vector.Path, func(t *testing.T) {
+				key, err := wallet.DeriveExtendedKey(vector.Path)
+				require.NoError(t, err)
+
+				privKey, err := key.ECPrivKey()
+				require.NoError(t, err)
+
+				got := crypto.PubkeyToAddress(privKey.ToECDSA().PublicKey)
+
+				assert.Equal(t, common.HexToAddress(vector.Address), got)
+				assert.Equal(t, vector.Address, got.Hex())
+			})
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package hdwallet
+
+import (
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// DerivationScheme describes the derivation path layout used by a wallet application
+// for Ethereum-like addresses.
+type DerivationScheme struct {
+	// Name is a human-readable name for the scheme.
+	Name string
+	// Path returns the derivation path of the address at addressIdx in the account at accountIdx.
+	Path func(coinType CoinType, accountIdx, addressIdx int) accounts.DerivationPath
+}
+
+var (
+	// SchemeBIP44 is the standard BIP44 layout, m/44'/coin_type'/account'/0/index.
+	SchemeBIP44 = DerivationScheme{Name: "BIP44", Path: bip44Path}
+	// SchemeMetaMask is the layout shared by MetaMask, MyEtherWallet, Trezor, Exodus
+	// and Coinbase Wallet, m/44'/coin_type'/0'/0/index.
+	SchemeMetaMask = DerivationScheme{Name: "MetaMask/MyEtherWallet/Trezor/Exodus/Coinbase Wallet", Path: firstAccountPath}
+	// SchemeLedgerLive is Ledger Live's layout, m/44'/coin_type'/account'/0/0,
+	// where every address gets its own account.
+	SchemeLedgerLive = DerivationScheme{Name: "Ledger Live", Path: ledgerLivePath}
+	// SchemeLedgerLegacy is the layout used by the Ledger Chrome app and
+	// MyEtherWallet's "Ledger (ETH)" option, m/44'/coin_type'/account'/index.
+	SchemeLedgerLegacy = DerivationScheme{Name: "Ledger Legacy", Path: ledgerLegacyPath}
+)
+
+// DefaultDiscoverySchemes returns the derivation schemes scanned by DiscoverDerivationPath
+// when no schemes are passed using WithDiscoverySchemes.
+func DefaultDiscoverySchemes() []DerivationScheme {
+	return []DerivationScheme{
+		SchemeMetaMask,
+		SchemeLedgerLive,
+		SchemeLedgerLegacy,
+		SchemeBIP44,
+	}
+}
+
+func bip44Path(coinType CoinType, accountIdx, addressIdx int) accounts.DerivationPath {
+	return accounts.DerivationPath{
+		hdkeychain.HardenedKeyStart + 44,
+		hdkeychain.HardenedKeyStart + uint32(coinType),
+		hdkeychain.HardenedKeyStart + uint32(accountIdx),
+		0,
+		uint32(addressIdx),
+	}
+}
+
+func firstAccountPath(coinType CoinType, _, addressIdx int) accounts.DerivationPath {
+	return bip44Path(coinType, 0, addressIdx)
+}
+
+func ledgerLivePath(coinType CoinType, accountIdx, _ int) accounts.DerivationPath {
+	return bip44Path(coinType, accountIdx, 0)
+}
+
+func ledgerLegacyPath(coinType CoinType, accountIdx, addressIdx int) accounts.DerivationPath {
+	return accounts.DerivationPath{
+		hdkeychain.HardenedKeyStart + 44,
+		hdkeychain.HardenedKeyStart + uint32(coinType),
+		hdkeychain.HardenedKeyStart + uint32(accountIdx),
+		uint32(addressIdx),
+	}
+}
+
+// DiscoveredAddress is an address found by DiscoverDerivationPath or DiscoverUsedAddresses.
+type DiscoveredAddress struct {
+	Address        common.Address
+	Scheme         DerivationScheme
+	DerivationPath accounts.DerivationPath
+}
+
+// AddressUsageChecker reports whether an address has been used,
+// for example by checking its on-chain transaction count or balance.
+type AddressUsageChecker func(address common.Address) (bool, error)
+
+type discoveryOpts struct {
+	schemes      []DerivationScheme
+	coinType     CoinType
+	accountStart int
+	accountEnd   int
+	indexStart   int
+	indexEnd     int
+}
+
+type funcDiscoveryOpt struct {
+	f func(*discoveryOpts)
+}
+
+func newFuncDiscoveryOpt(f func(*discoveryOpts)) *funcDiscoveryOpt {
+	return &funcDiscoveryOpt{f}
+}
+
+func (fo *funcDiscoveryOpt) apply(opts *discoveryOpts) {
+	fo.f(opts)
+}
+
+type DiscoveryOpt interface {
+	apply(*discoveryOpts)
+}
+
+// WithDiscoverySchemes sets the derivation schemes to scan.
+// Defaults to DefaultDiscoverySchemes().
+func WithDiscoverySchemes(schemes ...DerivationScheme) DiscoveryOpt {
+	return newFuncDiscoveryOpt(func(opts *discoveryOpts) {
+		opts.schemes = schemes
+	})
+}
+
+// WithDiscoveryCoinType sets the SLIP-44 coin type used in scanned derivation paths.
+// Defaults to CoinTypeEthereum.
+func WithDiscoveryCoinType(coinType CoinType) DiscoveryOpt {
+	return newFuncDiscoveryOpt(func(opts *discoveryOpts) {
+		opts.coinType = coinType
+	})
+}
+
+// WithDiscoveryAccounts sets the range [start, end) of account indices to scan.
+// Defaults to [0, 5).
+func WithDiscoveryAccounts(start, end int) DiscoveryOpt {
+	return newFuncDiscoveryOpt(func(opts *discoveryOpts) {
+		opts.accountStart = start
+		opts.accountEnd = end
+	})
+}
+
+// WithDiscoveryIndices sets the range [start, end) of address indices to scan in each account.
+// Defaults to [0, 20).
+func WithDiscoveryIndices(start, end int) DiscoveryOpt {
+	return newFuncDiscoveryOpt(func(opts *discoveryOpts) {
+		opts.indexStart = start
+		opts.indexEnd = end
+	})
+}
+
+func defaultDiscoveryOpts() *discoveryOpts {
+	return &discoveryOpts{
+		schemes:    DefaultDiscoverySchemes(),
+		coinType:   CoinTypeEthereum,
+		accountEnd: 5,
+		indexEnd:   20,
+	}
+}
+
+func (o *discoveryOpts) validate() error {
+	if o.accountStart < 0 || o.accountEnd < o.accountStart || isHardenedIdx(o.accountEnd-1) {
+		return errors.Errorf("invalid account range [%d, %d)", o.accountStart, o.accountEnd)
+	}
+
+	if o.indexStart < 0 || o.indexEnd < o.indexStart || isHardenedIdx(o.indexEnd-1) {
+		return errors.Errorf("invalid address index range [%d, %d)", o.indexStart, o.indexEnd)
+	}
+
+	return nil
+}
+
+// DiscoverDerivationPath scans the derivation schemes used by common wallet applications
+// for the passed target address, returning the first matching address and whether
+// the target was found.
+// This generalizes MnemonicHasAddress to seeds created by wallets other than MetaMask.
+func DiscoverDerivationPath(mnemonic, passphrase string, target common.Address, opts ...DiscoveryOpt) (*DiscoveredAddress, bool, error) {
+	var found *DiscoveredAddress
+
+	err := discover(mnemonic, passphrase, opts, func(d *DiscoveredAddress) (bool, error) {
+		if addressEq(d.Address, target) {
+			found = d
+			return true, nil
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return found, found != nil, nil
+}
+
+// DiscoverUsedAddresses scans the derivation schemes used by common wallet applications,
+// returning every address for which isUsed returns true.
+func DiscoverUsedAddresses(mnemonic, passphrase string, isUsed AddressUsageChecker, opts ...DiscoveryOpt) ([]*DiscoveredAddress, error) {
+	var used []*DiscoveredAddress
+
+	err := discover(mnemonic, passphrase, opts, func(d *DiscoveredAddress) (bool, error) {
+		ok, err := isUsed(d.Address)
+		if err != nil {
+			return false, errors.Wrapf(err, "error checking usage of address %s", d.Address)
+		}
+
+		if ok {
+			used = append(used, d)
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return used, nil
+}
+
+// discover calls visit for every unique derivation path described by opts,
+// stopping once visit returns true or an error.
+func discover(mnemonic, passphrase string, opts []DiscoveryOpt, visit func(*DiscoveredAddress) (bool, error)) error {
+	dOpts := defaultDiscoveryOpts()
+	for _, o := range opts {
+		o.apply(dOpts)
+	}
+
+	if err := dOpts.validate(); err != nil {
+		return err
+	}
+
+	wallet, err := NewHDWallet(WithPassphrase(passphrase), WithMnemonic(mnemonic))
+	if err != nil {
+		return err
+	}
+
+	masterKey := wallet.MasterKey()
+	defer masterKey.Zero()
+
+	var (
+		seen       = make(map[string]struct{})
+		parentKeys = make(map[string]*hdkeychain.ExtendedKey)
+	)
+
+	defer func() {
+		for _, key := range parentKeys {
+			key.Zero()
+		}
+	}()
+
+	for _, scheme := range dOpts.schemes {
+		for accountIdx := dOpts.accountStart; accountIdx < dOpts.accountEnd; accountIdx++ {
+			for addressIdx := dOpts.indexStart; addressIdx < dOpts.indexEnd; addressIdx++ {
+				path := scheme.Path(dOpts.coinType, accountIdx, addressIdx)
+				if len(path) == 0 {
+					return errors.Errorf("derivation scheme %s returned an empty path", scheme.Name)
+				}
+
+				if _, ok := seen[path.String()]; ok {
+					continue
+				}
+
+				seen[path.String()] = struct{}{}
+
+				parentPath := path[:len(path)-1]
+
+				parentKey, ok := parentKeys[parentPath.String()]
+				if !ok {
+					parentKey, err = deriveExtendedKey(masterKey, parentPath, false)
+					if err != nil {
+						return err
+					}
+
+					parentKeys[parentPath.String()] = parentKey
+				}
+
+				address, err := childAddress(parentKey, path[len(path)-1])
+				if err != nil {
+					return err
+				}
+
+				stop, err := visit(&DiscoveredAddress{
+					Address:        address,
+					Scheme:         scheme,
+					DerivationPath: path,
+				})
+
+				if err != nil || stop {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package hdwallet_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+func addressAtPath(t *testing.T, mnemonic, path string) common.Address {
+	t.Helper()
+
+	key, err := hdkeychain.NewMaster(bip39.NewSeed(mnemonic, ""), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range derivationPath {
+		if key, err = key.Derive(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	privKey, err := key.ECPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return crypto.PubkeyToAddress(privKey.ToECDSA().PublicKey)
+}
+
+func TestDiscoverDerivationPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     common.Address
+		opts       []hdwallet.DiscoveryOpt
+		wantFound  bool
+		wantScheme string
+		wantPath   string
+		wantErr    assert.ErrorAssertionFunc
+	}{
+		{
+			"metamask index 0",
+			common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"),
+			nil,
+			true,
+			hdwallet.SchemeMetaMask.Name,
+			"m/44'/60'/0'/0/0",
+			assert.NoError,
+		},
+		{
+			"metamask index 7",
+			addressAtPath(t, testMnemonicZero, "m/44'/60'/0'/0/7"),
+			nil,
+			true,
+			hdwallet.SchemeMetaMask.Name,
+			"m/44'/60'/0'/0/7",
+			assert.NoError,
+		},
+		{
+			"ledger live account 3",
+			addressAtPath(t, testMnemonicZero, "m/44'/60'/3'/0/0"),
+			nil,
+			true,
+			hdwallet.SchemeLedgerLive.Name,
+			"m/44'/60'/3'/0/0",
+			assert.NoError,
+		},
+		{
+			"ledger legacy index 4",
+			addressAtPath(t, testMnemonicZero, "m/44'/60'/0'/4"),
+			nil,
+			true,
+			hdwallet.SchemeLedgerLegacy.Name,
+			"m/44'/60'/0'/4",
+			assert.NoError,
+		},
+		{
+			"bip44 account 2 index 5",
+			addressAtPath(t, testMnemonicZero, "m/44'/60'/2'/0/5"),
+			nil,
+			true,
+			hdwallet.SchemeBIP44.Name,
+			"m/44'/60'/2'/0/5",
+			assert.NoError,
+		},
+		{
+			"ethereum classic coin type",
+			addressAtPath(t, testMnemonicZero, "m/44'/61'/0'/0/1"),
+			[]hdwallet.DiscoveryOpt{hdwallet.WithDiscoveryCoinType(hdwallet.CoinTypeEthereumClassic)},
+			true,
+			hdwallet.SchemeMetaMask.Name,
+			"m/44'/61'/0'/0/1",
+			assert.NoError,
+		},
+		{
+			"out of index range",
+			addressAtPath(t, testMnemonicZero, "m/44'/60'/0'/0/30"),
+			nil,
+			false,
+			"",
+			"",
+			assert.NoError,
+		},
+		{
+			"invalid range",
+			common.Address{},
+			[]hdwallet.DiscoveryOpt{hdwallet.WithDiscoveryIndices(10, 5)},
+			false,
+			"",
+			"",
+			assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found, err := hdwallet.DiscoverDerivationPath(testMnemonicZero, "", tt.target, tt.opts...)
+			if !tt.wantErr(t, err, fmt.Sprintf("DiscoverDerivationPath(%v)", tt.target)) || err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.wantFound, found)
+			if !found {
+				return
+			}
+
+			assert.Equal(t, tt.target, got.Address)
+			assert.Equal(t, tt.wantScheme, got.Scheme.Name)
+			assert.Equal(t, tt.wantPath, got.DerivationPath.String())
+		})
+	}
+}
+
+func TestDiscoverUsedAddresses(t *testing.T) {
+	used := map[common.Address]struct{}{
+		addressAtPath(t, testMnemonicZero, "m/44'/60'/0'/0/0"): {},
+		addressAtPath(t, testMnemonicZero, "m/44'/60'/1'/0/0"): {},
+		addressAtPath(t, testMnemonicZero, "m/44'/60'/0'/2"):   {},
+	}
+
+	got, err := hdwallet.DiscoverUsedAddresses(testMnemonicZero, "", func(address common.Address) (bool, error) {
+		_, ok := used[address]
+		return ok, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, got, len(used))
+
+	for _, d := range got {
+		assert.Contains(t, used, d.Address)
+	}
+}
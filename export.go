@@ -0,0 +1,199 @@
+package hdwallet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AddressRecord holds the public metadata of a derived address,
+// as exported by HDWallet.ExportAddressesJSON and HDWallet.ExportAddressesCSV.
+type AddressRecord struct {
+	// Address is the lowercase hex-encoded address.
+	Address string `json:"address"`
+	// ChecksumAddress is the EIP-55 checksummed address.
+	ChecksumAddress string `json:"checksum_address"`
+	// PublicKey is the hex-encoded uncompressed public key, without its 0x04 prefix.
+	PublicKey      string `json:"public_key"`
+	DerivationPath string `json:"derivation_path"`
+	Account        int    `json:"account"`
+	Index          int    `json:"index"`
+	Hardened       bool   `json:"hardened"`
+	// Imported is set for non-deterministic addresses created from raw private keys,
+	// which have no derivation path.
+	Imported bool `json:"imported"`
+}
+
+// addressRecordColumns is the CSV header written by HDWallet.ExportAddressesCSV.
+var addressRecordColumns = []string{
+	"address",
+	"checksum_address",
+	"public_key",
+	"derivation_path",
+	"account",
+	"index",
+	"hardened",
+	"imported",
+}
+
+// Record returns the address' public metadata.
+func (a HDWalletAddress) Record() AddressRecord {
+	return AddressRecord{
+		Address:         strings.ToLower(a.address.Hex()),
+		ChecksumAddress: a.address.Hex(),
+		PublicKey:       a.PublicKeyHex(),
+		DerivationPath:  a.DerivationPath(),
+		Account:         a.accountIndex,
+		Index:           a.derivationIndex,
+		Hardened:        a.hardened,
+		Imported:        a.imported,
+	}
+}
+
+// AddressRecords returns the public metadata of every address returned by Accounts().
+func (w HDWallet) AddressRecords() []AddressRecord {
+	addrs := w.addresses()
+
+	records := make([]AddressRecord, len(addrs))
+	for i, addr := range addrs {
+		records[i] = addr.Record()
+	}
+
+	return records
+}
+
+// ExportAddressesJSON writes the public metadata of every address returned by Accounts()
+// to out as a JSON array.
+func (w HDWallet) ExportAddressesJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(w.AddressRecords()); err != nil {
+		return errors.Wrap(err, "error encoding address records")
+	}
+
+	return nil
+}
+
+// ExportAddressesCSV writes the public metadata of every address returned by Accounts()
+// to out as CSV, starting with a header row.
+func (w HDWallet) ExportAddressesCSV(out io.Writer) error {
+	csvOut := csv.NewWriter(out)
+
+	if err := csvOut.Write(addressRecordColumns); err != nil {
+		return errors.Wrap(err, "error writing csv header")
+	}
+
+	for _, record := range w.AddressRecords() {
+		if err := csvOut.Write(record.csvRow()); err != nil {
+			return errors.Wrapf(err, "error writing csv row for address %s", record.ChecksumAddress)
+		}
+	}
+
+	csvOut.Flush()
+
+	return errors.Wrap(csvOut.Error(), "error writing csv")
+}
+
+func (r AddressRecord) csvRow() []string {
+	return []string{
+		r.Address,
+		r.ChecksumAddress,
+		r.PublicKey,
+		r.DerivationPath,
+		strconv.Itoa(r.Account),
+		strconv.Itoa(r.Index),
+		strconv.FormatBool(r.Hardened),
+		strconv.FormatBool(r.Imported),
+	}
+}
+
+// ImportAddressesJSON reads address records written by HDWallet.ExportAddressesJSON,
+// returning a watch-only view of them.
+func ImportAddressesJSON(in io.Reader) (*WatchOnlyWallet, error) {
+	var records []AddressRecord
+	if err := json.NewDecoder(in).Decode(&records); err != nil {
+		return nil, errors.Wrap(err, "error decoding address records")
+	}
+
+	return NewWatchOnlyWallet(records...)
+}
+
+// ImportAddressesCSV reads address records written by HDWallet.ExportAddressesCSV,
+// returning a watch-only view of them.
+// Columns are matched using the header row, so they may be reordered;
+// the imported column may be omitted, in which case every address is treated as derived.
+func ImportAddressesCSV(in io.Reader) (*WatchOnlyWallet, error) {
+	rows, err := csv.NewReader(in).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading csv")
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("csv has no header row")
+	}
+
+	columns := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range addressRecordColumns {
+		if _, ok := columns[name]; !ok && name != "imported" {
+			return nil, errors.Errorf("csv is missing column %q", name)
+		}
+	}
+
+	records := make([]AddressRecord, 0, len(rows)-1)
+
+	for i, row := range rows[1:] {
+		record, err := addressRecordFromCSV(columns, row)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid csv row %d", i+2)
+		}
+
+		records = append(records, record)
+	}
+
+	return NewWatchOnlyWallet(records...)
+}
+
+func addressRecordFromCSV(columns map[string]int, row []string) (AddressRecord, error) {
+	var (
+		record AddressRecord
+		err    error
+	)
+
+	field := func(name string) string {
+		return row[columns[name]]
+	}
+
+	record.Address = field("address")
+	record.ChecksumAddress = field("checksum_address")
+	record.PublicKey = field("public_key")
+	record.DerivationPath = field("derivation_path")
+
+	if record.Account, err = strconv.Atoi(field("account")); err != nil {
+		return record, errors.Wrap(err, "invalid account")
+	}
+
+	if record.Index, err = strconv.Atoi(field("index")); err != nil {
+		return record, errors.Wrap(err, "invalid index")
+	}
+
+	if record.Hardened, err = strconv.ParseBool(field("hardened")); err != nil {
+		return record, errors.Wrap(err, "invalid hardened flag")
+	}
+
+	if _, ok := columns["imported"]; ok {
+		if record.Imported, err = strconv.ParseBool(field("imported")); err != nil {
+			return record, errors.Wrap(err, "invalid imported flag")
+		}
+	}
+
+	return record, nil
+}
@@ -0,0 +1,134 @@
+package hdwallet_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+func TestHDWallet_ExportAddresses(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	_, err = w.DeriveRange(0, 3)
+	require.NoError(t, err)
+
+	_, err = w.DeriveAddressFromIndex(hdkeychain.HardenedKeyStart + 7)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		export func(*bytes.Buffer) error
+		parse  func(*bytes.Buffer) (*hdwallet.WatchOnlyWallet, error)
+	}{
+		{
+			"json",
+			func(buf *bytes.Buffer) error { return w.ExportAddressesJSON(buf) },
+			func(buf *bytes.Buffer) (*hdwallet.WatchOnlyWallet, error) { return hdwallet.ImportAddressesJSON(buf) },
+		},
+		{
+			"csv",
+			func(buf *bytes.Buffer) error { return w.ExportAddressesCSV(buf) },
+			func(buf *bytes.Buffer) (*hdwallet.WatchOnlyWallet, error) { return hdwallet.ImportAddressesCSV(buf) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tt.export(&buf))
+
+			for _, addr := range w.Accounts() {
+				privKey, err := addr.PrivateKeyHex()
+				require.NoError(t, err)
+
+				assert.NotContains(t, buf.String(), privKey)
+			}
+
+			watchOnly, err := tt.parse(&buf)
+			require.NoError(t, err)
+
+			imported := watchOnly.Addresses()
+			require.Len(t, imported, len(w.Accounts()))
+
+			for i, addr := range w.Accounts() {
+				got := imported[i]
+
+				assert.Equal(t, addr.Address(), got.Address())
+				assert.Equal(t, addr.PublicKeyHex(), got.PublicKeyHex())
+				assert.Equal(t, addr.DerivationPath(), got.DerivationPath())
+				assert.Equal(t, addr.DerivationIndex(), got.DerivationIndex())
+				assert.Equal(t, addr.Hardened(), got.Hardened())
+
+				found, ok := watchOnly.FindByAddress(addr.Address())
+				assert.True(t, ok)
+				assert.Same(t, got, found)
+
+				found, ok = watchOnly.FindByPath(addr.DerivationPath())
+				assert.True(t, ok)
+				assert.Same(t, got, found)
+			}
+		})
+	}
+}
+
+func TestHDWallet_ExportAddressesCSV(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	addr, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, w.ExportAddressesCSV(&buf))
+
+	assert.Equal(
+		t,
+		"address,checksum_address,public_key,derivation_path,account,index,hardened,imported\n"+
+			strings.ToLower(addr.Address().Hex())+","+addr.Address().Hex()+","+addr.PublicKeyHex()+",m/44'/60'/0'/0/0,0,0,false,false\n",
+		buf.String(),
+	)
+}
+
+func TestImportAddresses_Invalid(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	_, err = w.DeriveRange(0, 2)
+	require.NoError(t, err)
+
+	records := w.AddressRecords()
+
+	tests := []struct {
+		name   string
+		modify func(*hdwallet.AddressRecord)
+	}{
+		{"wrong address", func(r *hdwallet.AddressRecord) { r.Address = records[1].Address }},
+		{"wrong checksum", func(r *hdwallet.AddressRecord) { r.ChecksumAddress = strings.ToLower(r.ChecksumAddress) }},
+		{"invalid public key", func(r *hdwallet.AddressRecord) { r.PublicKey = "04" }},
+		{"relative path", func(r *hdwallet.AddressRecord) { r.DerivationPath = "0/0" }},
+		{"short path", func(r *hdwallet.AddressRecord) { r.DerivationPath = "m/44'" }},
+		{"mismatched index", func(r *hdwallet.AddressRecord) { r.Index = 5 }},
+		{"mismatched hardened", func(r *hdwallet.AddressRecord) { r.Hardened = true }},
+		{"duplicate path", func(r *hdwallet.AddressRecord) { *r = records[1] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := make([]hdwallet.AddressRecord, len(records))
+			copy(modified, records)
+
+			tt.modify(&modified[0])
+
+			_, err := hdwallet.NewWatchOnlyWallet(modified...)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = hdwallet.ImportAddressesCSV(strings.NewReader("address,public_key\n"))
+	assert.Error(t, err, "missing columns")
+}
@@ -0,0 +1,70 @@
+package hdwallet
+
+import (
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+)
+
+// ParseExtendedKey parses a base58-encoded extended public or private key,
+// rejecting every invalid key listed in BIP32 test vector 5.
+// On top of the checks done by hdkeychain.NewKeyFromString, the key's
+// version bytes must be known (for one of btcd's networks or SLIP-132),
+// must match the kind of key data, and keys at depth 0 must have a zero
+// parent fingerprint and child index.
+func ParseExtendedKey(key string) (*hdkeychain.ExtendedKey, error) {
+	extKey, err := hdkeychain.NewKeyFromString(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid extended key")
+	}
+
+	var version [4]byte
+	copy(version[:], extKey.Version())
+
+	privateVersion, known := knownExtendedKeyVersions()[version]
+	if !known {
+		return nil, errors.Errorf("unknown extended key version %x", version)
+	}
+
+	if privateVersion != extKey.IsPrivate() {
+		return nil, errors.New("extended key version does not match key data")
+	}
+
+	if extKey.Depth() == 0 {
+		if extKey.ParentFingerprint() != 0 {
+			return nil, errors.New("extended key has zero depth with non-zero parent fingerprint")
+		}
+
+		if extKey.ChildIndex() != 0 {
+			return nil, errors.New("extended key has zero depth with non-zero index")
+		}
+	}
+
+	return extKey, nil
+}
+
+// knownExtendedKeyVersions returns the set of known extended key version bytes,
+// mapped to whether they're used for private keys.
+func knownExtendedKeyVersions() map[[4]byte]bool {
+	versions := make(map[[4]byte]bool)
+
+	for _, netParams := range []*chaincfg.Params{
+		&chaincfg.MainNetParams,
+		&chaincfg.TestNet3Params,
+		&chaincfg.RegressionNetParams,
+		&chaincfg.SimNetParams,
+		&chaincfg.SigNetParams,
+	} {
+		versions[netParams.HDPrivateKeyID] = true
+		versions[netParams.HDPublicKeyID] = false
+	}
+
+	for _, slip132 := range []map[BitcoinAddressType]slip132Versions{slip132MainNet, slip132TestNet} {
+		for _, v := range slip132 {
+			versions[v.private] = true
+			versions[v.public] = false
+		}
+	}
+
+	return versions
+}
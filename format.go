@@ -0,0 +1,332 @@
+package hdwallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+// HDWallet, HDWalletAddress and BitcoinAddress hold secrets (mnemonic, seed and private keys),
+// so they implement fmt.Formatter, fmt.GoStringer, slog.LogValuer and json.Marshaler
+// to make sure only public data is ever printed, logged or serialized.
+// Secrets can only be exported using ExportSecrets, or BitcoinAddress.WIF.
+var (
+	_ fmt.Formatter  = HDWalletAddress{}
+	_ fmt.GoStringer = HDWalletAddress{}
+	_ slog.LogValuer = HDWalletAddress{}
+	_ json.Marshaler = HDWalletAddress{}
+	_ fmt.Formatter  = HDWallet{}
+	_ fmt.GoStringer = HDWallet{}
+	_ slog.LogValuer = HDWallet{}
+	_ json.Marshaler = HDWallet{}
+	_ fmt.Formatter  = BitcoinAddress{}
+	_ fmt.GoStringer = BitcoinAddress{}
+	_ slog.LogValuer = BitcoinAddress{}
+	_ json.Marshaler = BitcoinAddress{}
+)
+
+// addressJSON is the public JSON representation of an HDWalletAddress.
+type addressJSON struct {
+	Address        string `json:"address"`
+	PublicKey      string `json:"public_key"`
+	DerivationPath string `json:"derivation_path"`
+	Hardened       bool   `json:"hardened"`
+	Imported       bool   `json:"imported,omitempty"`
+}
+
+// bitcoinAddressJSON is the public JSON representation of a BitcoinAddress.
+type bitcoinAddressJSON struct {
+	Address        string `json:"address"`
+	Type           string `json:"type"`
+	PublicKey      string `json:"public_key"`
+	DerivationPath string `json:"derivation_path"`
+	Network        string `json:"network"`
+}
+
+// walletJSON is the public JSON representation of an HDWallet.
+type walletJSON struct {
+	Network   string        `json:"network"`
+	CoinType  CoinType      `json:"coin_type"`
+	Addresses []addressJSON `json:"addresses"`
+}
+
+// AddressSecrets holds an address' private key, as returned by HDWalletAddress.ExportSecrets.
+type AddressSecrets struct {
+	Address        string `json:"address"`
+	DerivationPath string `json:"derivation_path"`
+	PrivateKey     string `json:"private_key"`
+}
+
+// WalletSecrets holds a wallet's secrets, as returned by HDWallet.ExportSecrets.
+type WalletSecrets struct {
+	Mnemonic  string           `json:"mnemonic"`
+	Entropy   string           `json:"entropy"`
+	Seed      string           `json:"seed"`
+	MasterKey string           `json:"master_key"`
+	Addresses []AddressSecrets `json:"addresses"`
+}
+
+func (a HDWalletAddress) publicJSON() addressJSON {
+	return addressJSON{
+		Address:        a.address.Hex(),
+		PublicKey:      a.PublicKeyHex(),
+		DerivationPath: a.DerivationPath(),
+		Hardened:       a.hardened,
+		Imported:       a.imported,
+	}
+}
+
+// String returns the EIP-55 checksummed address.
+func (a HDWalletAddress) String() string {
+	return a.address.Hex()
+}
+
+// GoString returns a Go-syntax representation of the address' public data.
+func (a HDWalletAddress) GoString() string {
+	return fmt.Sprintf(
+		"hdwallet.HDWalletAddress{Address:%q, PublicKey:%q, DerivationPath:%q, Hardened:%t}",
+		a.address.Hex(), a.PublicKeyHex(), a.DerivationPath(), a.hardened,
+	)
+}
+
+// Format implements fmt.Formatter, only ever printing the address' public data:
+// %v and %s print the address, %+v also prints its public key, derivation path
+// and hardened flag, and %#v prints the result of GoString.
+func (a HDWalletAddress) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprint(f, a.GoString())
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(
+			f, "{Address:%s PublicKey:%s DerivationPath:%s Hardened:%t}",
+			a.address.Hex(), a.PublicKeyHex(), a.DerivationPath(), a.hardened,
+		)
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", a.String())
+	default:
+		fmt.Fprint(f, a.String())
+	}
+}
+
+// LogValue implements slog.LogValuer, only logging the address' public data.
+func (a HDWalletAddress) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("address", a.address.Hex()),
+		slog.String("public_key", a.PublicKeyHex()),
+		slog.String("derivation_path", a.DerivationPath()),
+		slog.Bool("hardened", a.hardened),
+		slog.Bool("imported", a.imported),
+	)
+}
+
+// MarshalJSON implements json.Marshaler, only serializing the address' public data.
+func (a HDWalletAddress) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.publicJSON())
+}
+
+// ExportSecrets returns the address' private key, or ErrLocked while its wallet is locked.
+// Unlike every other way of printing, logging or serializing an HDWalletAddress,
+// the returned value contains secrets and must be handled accordingly.
+func (a HDWalletAddress) ExportSecrets() (AddressSecrets, error) {
+	privKey, err := a.PrivateKeyHex()
+	if err != nil {
+		return AddressSecrets{}, err
+	}
+
+	return AddressSecrets{
+		Address:        a.address.Hex(),
+		DerivationPath: a.DerivationPath(),
+		PrivateKey:     privKey,
+	}, nil
+}
+
+// GoString returns a Go-syntax representation of the address' public data.
+func (a BitcoinAddress) GoString() string {
+	return fmt.Sprintf(
+		"hdwallet.BitcoinAddress{Address:%q, Type:%q, PublicKey:%q, DerivationPath:%q, Network:%q}",
+		a.String(), a.addressType, a.PublicKeyHex(), a.DerivationPath(), a.networkName(),
+	)
+}
+
+// Format implements fmt.Formatter, only ever printing the address' public data:
+// %v and %s print the encoded address, %+v also prints its type, public key,
+// derivation path and network, and %#v prints the result of GoString.
+func (a BitcoinAddress) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprint(f, a.GoString())
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(
+			f, "{Address:%s Type:%s PublicKey:%s DerivationPath:%s Network:%s}",
+			a.String(), a.addressType, a.PublicKeyHex(), a.DerivationPath(), a.networkName(),
+		)
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", a.String())
+	default:
+		fmt.Fprint(f, a.String())
+	}
+}
+
+// LogValue implements slog.LogValuer, only logging the address' public data.
+func (a BitcoinAddress) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("address", a.String()),
+		slog.String("type", a.addressType.String()),
+		slog.String("public_key", a.PublicKeyHex()),
+		slog.String("derivation_path", a.DerivationPath()),
+		slog.String("network", a.networkName()),
+	)
+}
+
+// MarshalJSON implements json.Marshaler, only serializing the address' public data.
+func (a BitcoinAddress) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bitcoinAddressJSON{
+		Address:        a.String(),
+		Type:           a.addressType.String(),
+		PublicKey:      a.PublicKeyHex(),
+		DerivationPath: a.DerivationPath(),
+		Network:        a.networkName(),
+	})
+}
+
+func (a BitcoinAddress) networkName() string {
+	if a.netParams == nil {
+		return ""
+	}
+
+	return a.netParams.Name
+}
+
+func (w HDWallet) networkName() string {
+	if w.netParams == nil {
+		return ""
+	}
+
+	return w.netParams.Name
+}
+
+func (w HDWallet) numAddresses() int {
+	if w.account == nil {
+		return 0
+	}
+
+	return len(w.account.derivedAddrs.all())
+}
+
+func (w HDWallet) addresses() []*HDWalletAddress {
+	if w.account == nil {
+		return nil
+	}
+
+	return w.account.derivedAddrs.all()
+}
+
+// String returns a summary of the wallet's public data.
+func (w HDWallet) String() string {
+	return fmt.Sprintf("HDWallet(network=%s, coinType=%s, addresses=%d)", w.networkName(), w.coinType, w.numAddresses())
+}
+
+// GoString returns a Go-syntax representation of the wallet's public data.
+func (w HDWallet) GoString() string {
+	addrs := w.addresses()
+
+	goStrings := make([]string, len(addrs))
+	for i, addr := range addrs {
+		goStrings[i] = addr.GoString()
+	}
+
+	return fmt.Sprintf(
+		"hdwallet.HDWallet{Network:%q, CoinType:%d, Addresses:[]*hdwallet.HDWalletAddress{%s}}",
+		w.networkName(), uint32(w.coinType), strings.Join(goStrings, ", "),
+	)
+}
+
+// Format implements fmt.Formatter, only ever printing the wallet's public data:
+// %v and %s print the result of String, %+v also prints every derived address,
+// and %#v prints the result of GoString.
+func (w HDWallet) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprint(f, w.GoString())
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "{Network:%s CoinType:%s Addresses:[", w.networkName(), w.coinType)
+
+		for i, addr := range w.addresses() {
+			if i > 0 {
+				fmt.Fprint(f, " ")
+			}
+
+			fmt.Fprintf(f, "%+v", addr)
+		}
+
+		fmt.Fprint(f, "]}")
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", w.String())
+	default:
+		fmt.Fprint(f, w.String())
+	}
+}
+
+// LogValue implements slog.LogValuer, only logging the wallet's public data.
+func (w HDWallet) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("network", w.networkName()),
+		slog.String("coin_type", w.coinType.String()),
+		slog.Int("addresses", w.numAddresses()),
+	)
+}
+
+// MarshalJSON implements json.Marshaler, only serializing the wallet's public data.
+func (w HDWallet) MarshalJSON() ([]byte, error) {
+	addrs := w.addresses()
+
+	data := walletJSON{
+		Network:   w.networkName(),
+		CoinType:  w.coinType,
+		Addresses: make([]addressJSON, len(addrs)),
+	}
+
+	for i, addr := range addrs {
+		data.Addresses[i] = addr.publicJSON()
+	}
+
+	return json.Marshal(data)
+}
+
+// ExportSecrets returns the wallet's mnemonic, entropy, seed and master key,
+// along with the private keys of every derived address, or ErrLocked while the wallet is locked.
+// Unlike every other way of printing, logging or serializing an HDWallet,
+// the returned value contains secrets and must be handled accordingly.
+func (w HDWallet) ExportSecrets() (WalletSecrets, error) {
+	var masterKey string
+
+	err := w.vault.withMaster(func(key *hdkeychain.ExtendedKey) error {
+		masterKey = key.String()
+		return nil
+	})
+	if err != nil {
+		return WalletSecrets{}, err
+	}
+
+	addrs := w.addresses()
+
+	secrets := WalletSecrets{
+		Mnemonic:  w.Mnemonic(),
+		Entropy:   hex.EncodeToString(w.Entropy()),
+		Seed:      hex.EncodeToString(w.Seed()),
+		MasterKey: masterKey,
+		Addresses: make([]AddressSecrets, len(addrs)),
+	}
+
+	for i, addr := range addrs {
+		if secrets.Addresses[i], err = addr.ExportSecrets(); err != nil {
+			return WalletSecrets{}, err
+		}
+	}
+
+	return secrets, nil
+}
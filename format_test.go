@@ -0,0 +1,148 @@
+package hdwallet_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+// TestSecretSafeFormatting checks that no way of printing, logging or serializing
+// a wallet or address leaks its mnemonic, seed, master key or private keys.
+func TestSecretSafeFormatting(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	addr, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	privKey, err := addr.PrivateKeyHex()
+	require.NoError(t, err)
+
+	secrets := []string{
+		"abandon",
+		hex.EncodeToString(w.Seed()),
+		hex.EncodeToString(w.Entropy()),
+		w.MasterKey().String(),
+		privKey,
+	}
+
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logBuf, nil))
+	logger.Info("wallet", "wallet", w, "address", addr, "walletValue", *w)
+	logger.Info("text", slog.Any("address", *addr))
+
+	walletJSON, err := json.Marshal(w)
+	require.NoError(t, err)
+
+	addrJSON, err := json.Marshal(addr)
+	require.NoError(t, err)
+
+	outputs := map[string]string{
+		"log":          logBuf.String(),
+		"wallet json":  string(walletJSON),
+		"address json": string(addrJSON),
+	}
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+		outputs["wallet "+verb] = fmt.Sprintf(verb, w)
+		outputs["wallet value "+verb] = fmt.Sprintf(verb, *w)
+		outputs["address "+verb] = fmt.Sprintf(verb, addr)
+		outputs["address value "+verb] = fmt.Sprintf(verb, *addr)
+		outputs["address slice "+verb] = fmt.Sprintf(verb, []*hdwallet.HDWalletAddress{addr})
+	}
+
+	for name, out := range outputs {
+		for _, secret := range secrets {
+			assert.False(t, strings.Contains(out, secret), "%s output %q contains a secret", name, out)
+		}
+	}
+
+	assert.Equal(t, addr.Address().Hex(), fmt.Sprint(addr))
+	assert.Contains(t, fmt.Sprintf("%+v", w), addr.PublicKeyHex())
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"address":%q,"public_key":%q,"derivation_path":"m/44'/60'/0'/0/0","hardened":false}`,
+		addr.Address().Hex(), addr.PublicKeyHex(),
+	), string(addrJSON))
+}
+
+func TestBitcoinAddress_SecretSafeFormatting(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	addr, err := w.DeriveBitcoinAddress(hdwallet.BitcoinP2WPKH, 0, false, 0)
+	require.NoError(t, err)
+
+	privKey, err := addr.PrivateKey()
+	require.NoError(t, err)
+
+	wif, err := addr.WIF()
+	require.NoError(t, err)
+
+	secrets := []string{
+		hex.EncodeToString(privKey.Serialize()),
+		wif,
+	}
+
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logBuf, nil))
+	logger.Info("address", "address", addr, "addressValue", *addr)
+
+	addrJSON, err := json.Marshal(addr)
+	require.NoError(t, err)
+
+	outputs := map[string]string{
+		"log":          logBuf.String(),
+		"address json": string(addrJSON),
+	}
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+		outputs["address "+verb] = fmt.Sprintf(verb, addr)
+		outputs["address value "+verb] = fmt.Sprintf(verb, *addr)
+		outputs["address slice "+verb] = fmt.Sprintf(verb, []*hdwallet.BitcoinAddress{addr})
+	}
+
+	for name, out := range outputs {
+		for _, secret := range secrets {
+			assert.False(t, strings.Contains(out, secret), "%s output %q contains a secret", name, out)
+		}
+	}
+
+	assert.Equal(t, addr.String(), fmt.Sprint(addr))
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"address":%q,"type":"p2wpkh","public_key":%q,"derivation_path":"m/84'/0'/0'/0/0","network":"mainnet"}`,
+		addr.String(), addr.PublicKeyHex(),
+	), string(addrJSON))
+}
+
+func TestHDWallet_ExportSecrets(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	addr, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	secrets, err := w.ExportSecrets()
+	require.NoError(t, err)
+
+	addrSecrets, err := addr.ExportSecrets()
+	require.NoError(t, err)
+
+	privKey, err := addr.PrivateKeyHex()
+	require.NoError(t, err)
+
+	assert.Equal(t, testMnemonicZero, secrets.Mnemonic)
+	assert.Equal(t, hex.EncodeToString(w.Seed()), secrets.Seed)
+	assert.Equal(t, hex.EncodeToString(w.Entropy()), secrets.Entropy)
+	assert.Equal(t, w.MasterKey().String(), secrets.MasterKey)
+	assert.Equal(t, []hdwallet.AddressSecrets{addrSecrets}, secrets.Addresses)
+	assert.Equal(t, privKey, secrets.Addresses[0].PrivateKey)
+}
@@ -0,0 +1,117 @@
+package hdwallet_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+const testEntropyHex string = "b689a63adcc87720ebf67d8e4b9e8dd9156b1dab182665b904959f0ef9f9873f"
+
+func FuzzEntropyFromString(f *testing.F) {
+	for _, seed := range []string{"", "0x", "00ff7f80", "0X00FF", "0x00f", "zz", testEntropyHex} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, entropy string) {
+		got, err := hdwallet.EntropyFromString(entropy)
+		if err != nil {
+			return
+		}
+
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(entropy, "0x"), "0X")
+		if !strings.EqualFold(hex.EncodeToString(got), trimmed) {
+			t.Fatalf("EntropyFromString(%q) = %x", entropy, got)
+		}
+	})
+}
+
+func FuzzWithMnemonic(f *testing.F) {
+	for _, seed := range []string{testMnemonicZero, testMnemonic7F, testMnemonicZero24, "hello world", "", "  abandon  "} {
+		f.Add(seed, "")
+	}
+
+	f.Add(testMnemonicZero, "TREZOR")
+
+	f.Fuzz(func(t *testing.T, mnemonic, passphrase string) {
+		// an empty mnemonic means one is generated.
+		if mnemonic == "" {
+			return
+		}
+
+		w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(mnemonic), hdwallet.WithPassphrase(passphrase))
+		if err != nil {
+			return
+		}
+
+		if !bip39.IsMnemonicValid(mnemonic) {
+			t.Fatalf("NewHDWallet accepted invalid mnemonic %q", mnemonic)
+		}
+
+		want := bip39.NewSeed(mnemonic, passphrase)
+		if hex.EncodeToString(w.Seed()) != hex.EncodeToString(want) {
+			t.Fatalf("seed mismatch for mnemonic %q", mnemonic)
+		}
+
+		if _, err := w.DeriveAddress(); err != nil {
+			t.Fatalf("DeriveAddress() error = %v", err)
+		}
+	})
+}
+
+func FuzzWithEntropy(f *testing.F) {
+	for _, seed := range []string{"", "00", "000102030405060708090a0b0c0d0e0f", testEntropyHex} {
+		entropy, _ := hex.DecodeString(seed)
+		f.Add(entropy)
+	}
+
+	f.Fuzz(func(t *testing.T, entropy []byte) {
+		w, err := hdwallet.NewHDWallet(hdwallet.WithEntropy(entropy))
+		if err != nil {
+			return
+		}
+
+		got, err := bip39.EntropyFromMnemonic(w.Mnemonic())
+		if err != nil {
+			t.Fatalf("wallet mnemonic %q is invalid: %v", w.Mnemonic(), err)
+		}
+
+		if hex.EncodeToString(got) != hex.EncodeToString(entropy) {
+			t.Fatalf("entropy %x round-tripped to %x", entropy, got)
+		}
+	})
+}
+
+func FuzzDeriveAddressFromIndex(f *testing.F) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, seed := range []int64{0, 1, -1, 1<<31 - 1, 1 << 31, 1<<32 - 1, 1 << 32, -1 << 63} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, idx int64) {
+		addr, err := w.DeriveAddressFromIndex(int(idx))
+		if err != nil {
+			if idx >= 0 && idx < 1<<32 {
+				t.Fatalf("DeriveAddressFromIndex(%d) error = %v", idx, err)
+			}
+
+			return
+		}
+
+		if idx < 0 || idx >= 1<<32 {
+			t.Fatalf("DeriveAddressFromIndex(%d) accepted an out of range index", idx)
+		}
+
+		if addr.DerivationPath() == "" {
+			t.Fatalf("DeriveAddressFromIndex(%d) has no derivation path", idx)
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package hdwallet
 
 import (
-	"strings"
 	"sync"
 
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
@@ -11,20 +10,22 @@ import (
 
 // HDWallet represents a BIP32/BIP44 Hierarchical Deterministic Wallet.
 type HDWallet struct {
-	masterKey   *hdkeychain.ExtendedKey
-	seed        []byte
-	entropy     []byte
-	mnemonic    []string
-	entropyBits int
-	account     *walletAccount
-	initOnce    *sync.Once
-	opts        *walletOpts
+	vault            *vault
+	entropyBits      int
+	netParams        *chaincfg.Params
+	coinType         CoinType
+	account          *walletAccount
+	bitcoinAddrs     *bitcoinRegistry
+	legacyDerivation bool
+	initOnce         *sync.Once
+	opts             *walletOpts
 }
 
 func newEmptyHDWallet(opts ...NewWalletOpt) *HDWallet {
 	w := &HDWallet{
-		initOnce: new(sync.Once),
-		opts:     defaultWalletOpts(),
+		bitcoinAddrs: newBitcoinRegistry(),
+		initOnce:     new(sync.Once),
+		opts:         defaultWalletOpts(),
 	}
 
 	for _, o := range opts {
@@ -36,6 +37,10 @@ func newEmptyHDWallet(opts ...NewWalletOpt) *HDWallet {
 
 // NewHDWallet constructs and returns an *HDWallet instance
 // using any passed NewWalletOpt parameters.
+//
+// Only wallets created using WithLockPassword can be locked: without it, the wallet's
+// secrets and every private key it derives stay decrypted in memory for its whole
+// lifetime, and HDWallet.Lock returns ErrNotLockable.
 func NewHDWallet(opts ...NewWalletOpt) (*HDWallet, error) {
 	return newEmptyHDWallet(opts...).init()
 }
@@ -44,31 +49,69 @@ func (w *HDWallet) init() (*HDWallet, error) {
 	var initErr error
 
 	w.initOnce.Do(func() {
-		bip39Data, err := makeBIP39Data(w.opts)
+		var (
+			bip39Data = new(newBIP39Data)
+			keychain  *hdkeychain.ExtendedKey
+			opts      = w.opts
+			err       error
+		)
+
+		// the vault copies every secret, so wipe the ones used to create it.
+		defer func() {
+			if keychain != nil && keychain != opts.masterKey {
+				keychain.Zero()
+			}
+
+			wipeBIP39Data(bip39Data, opts)
+		}()
+
+		if w.opts.masterKey == nil {
+			bip39Data, err = makeBIP39Data(w.opts)
+			if err != nil {
+				initErr = errors.Wrap(err, "error generating bip39 data")
+				return
+			}
+		}
 
-		if err != nil {
-			initErr = errors.Wrap(err, "error generating bip39 data")
+		if w.opts.netParams == nil {
+			initErr = errors.New("network parameters must not be nil")
 			return
 		}
 
-		keychain, err := hdkeychain.NewMaster(bip39Data.Seed, &chaincfg.MainNetParams)
-		if err != nil {
-			initErr = errors.Wrap(err, "error creating master Extended Key")
+		if !w.opts.coinType.Registered() {
+			initErr = errors.Errorf("unregistered coin type %d", uint32(w.opts.coinType))
 			return
 		}
 
-		if bip39Data.Seed != nil {
-			w.seed = bip39Data.Seed
+		keychain, err = newMasterKey(w.opts.masterKey, bip39Data.Seed, w.opts.netParams)
+		if err != nil {
+			initErr = err
+			return
 		}
-		if bip39Data.Entropy != nil {
-			w.entropy = bip39Data.Entropy
+
+		w.vault, err = newVault(keychain, bip39Data.Seed, bip39Data.Entropy, bip39Data.Mnemonic, w.opts.strictMemoryLock)
+		if err != nil {
+			initErr = err
+			return
 		}
 
-		w.masterKey = keychain
-		w.mnemonic = strings.Split(bip39Data.Mnemonic, " ")
 		w.entropyBits = w.opts.entropyBits
+		w.netParams = w.opts.netParams
+		w.coinType = w.opts.coinType
+		w.legacyDerivation = w.opts.legacyDerivation
+
+		w.account, err = newWalletAccount(w.vault, w.coinType, 0, w.opts.newKeyForAccount, w.legacyDerivation)
+		if err != nil {
+			initErr = errors.Wrap(err, "error creating wallet account")
+			return
+		}
 
-		w.account = newWalletAccount(w.masterKey, 0, w.opts.newKeyForAccount)
+		if w.opts.lockPassword != nil {
+			if err = w.vault.enableLock(*w.opts.lockPassword); err != nil {
+				initErr = errors.Wrap(err, "error locking wallet")
+				return
+			}
+		}
 
 		w.opts = nil
 	})
@@ -80,6 +123,30 @@ func (w *HDWallet) init() (*HDWallet, error) {
 	return w, nil
 }
 
+// newMasterKey returns masterKey if it's set, making sure it's a private key at depth 0,
+// and otherwise creates the master key for seed.
+func newMasterKey(masterKey *hdkeychain.ExtendedKey, seed []byte, netParams *chaincfg.Params) (*hdkeychain.ExtendedKey, error) {
+	if masterKey == nil {
+		keychain, err := hdkeychain.NewMaster(seed, netParams)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating master Extended Key")
+		}
+
+		return keychain, nil
+	}
+
+	if !masterKey.IsPrivate() {
+		return nil, errors.New("master key must be an extended private key")
+	}
+
+	if masterKey.Depth() != 0 {
+		return nil, errors.Errorf("master key must be at depth 0, got depth %d", masterKey.Depth())
+	}
+
+	// newVault copies the key, so the caller's key isn't shared.
+	return masterKey, nil
+}
+
 // DeriveAddress derives a new, non-hardened child account using the next available
 // derivation index.
 // If the next available derivation index is the start of available "hardened"
@@ -128,22 +195,84 @@ func (w *HDWallet) DeriveAddressFromIndex(idx int) (*HDWalletAddress, error) {
 	return fancyDerived, nil
 }
 
+// DeriveExtendedKey derives the extended private key at the passed absolute
+// derivation path (for example "m/44'/60'/0'/0"), or at a path relative to
+// the wallet's m/44'/coin_type'/0'/0 (see ParseDerivationPath),
+// honoring WithLegacyDerivation.
+func (w *HDWallet) DeriveExtendedKey(path string) (*hdkeychain.ExtendedKey, error) {
+	derivationPath, err := ParseDerivationPath(w.coinType, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key *hdkeychain.ExtendedKey
+
+	err = w.vault.withMaster(func(masterKey *hdkeychain.ExtendedKey) (err error) {
+		if len(derivationPath) == 0 {
+			key, err = copyExtendedKey(nil, masterKey)
+			return err
+		}
+
+		key, err = deriveExtendedKey(masterKey, derivationPath, w.legacyDerivation)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// MasterKey returns a copy of the wallet's master extended private key,
+// or nil while the wallet is locked.
 func (w HDWallet) MasterKey() *hdkeychain.ExtendedKey {
-	return w.masterKey
+	var masterKey *hdkeychain.ExtendedKey
+
+	_ = w.vault.withMaster(func(key *hdkeychain.ExtendedKey) (err error) {
+		masterKey, err = copyExtendedKey(nil, key)
+		return err
+	})
+
+	return masterKey
+}
+
+// Network returns the chaincfg.Params used for serializing the wallet's
+// extended keys and encoding its Bitcoin addresses.
+func (w HDWallet) Network() *chaincfg.Params {
+	return w.netParams
+}
+
+// CoinType returns the SLIP-44 coin type used in the wallet's derivation paths.
+func (w HDWallet) CoinType() CoinType {
+	return w.coinType
 }
 
+// Mnemonic returns the wallet's BIP39 mnemonic, or an empty string while the wallet is locked.
 func (w HDWallet) Mnemonic() string {
-	return strings.Join(w.mnemonic, " ")
+	w.vault.mu.RLock()
+	defer w.vault.mu.RUnlock()
+
+	if w.vault.locked {
+		return ""
+	}
+
+	return string(w.vault.mnemonic.bytes())
 }
 
+// Seed returns a copy of the wallet's BIP32 seed, or nil while the wallet is locked.
 func (w HDWallet) Seed() []byte {
-	return w.seed
+	return w.vault.secret(w.vault.seed)
 }
 
+// Entropy returns a copy of the wallet's BIP39 entropy, or nil while the wallet is locked.
+// For wallets created using WithMnemonic, it's the entropy encoded by the mnemonic.
 func (w HDWallet) Entropy() []byte {
-	return w.entropy
+	return w.vault.secret(w.vault.entropy)
 }
 
+// Accounts returns every address derived and kept by the wallet,
+// in the order they were first derived and without duplicates.
 func (w HDWallet) Accounts() []*HDWalletAddress {
-	return w.account.derivedAddrs
+	return w.account.derivedAddrs.all()
 }
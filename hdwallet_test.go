@@ -5,13 +5,19 @@ import (
 	"testing"
 
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/jalavosus/hdwallet-go"
 )
 
 func TestNewHDWallet(t *testing.T) {
+	entropy, err := hdwallet.EntropyFromString(testEntropyHex)
+	require.NoError(t, err)
+
 	tests := []struct {
 		name    string
 		args    []hdwallet.NewWalletOpt
@@ -39,7 +45,7 @@ func TestNewHDWallet(t *testing.T) {
 		{
 			"with entropy",
 			[]hdwallet.NewWalletOpt{
-				hdwallet.WithEntropy(hdwallet.EntropyFromString("b689a63adcc87720ebf67d8e4b9e8dd9156b1dab182665b904959f0ef9f9873f")),
+				hdwallet.WithEntropy(entropy),
 			},
 			assert.NoError,
 		},
@@ -69,6 +75,43 @@ type hdWalletDeriveAccountFromIndexTestCase struct {
 	idx      int
 }
 
+func TestWithMnemonic_Entropy(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	assert.Equal(t, make([]byte, 16), w.Entropy())
+
+	fromEntropy, err := hdwallet.NewHDWallet(hdwallet.WithEntropy(w.Entropy()))
+	require.NoError(t, err)
+
+	assert.Equal(t, testMnemonicZero, fromEntropy.Mnemonic())
+}
+
+func TestEntropyFromString(t *testing.T) {
+	tests := []struct {
+		name    string
+		entropy string
+		want    []byte
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"hex", "00ff7f80", []byte{0x00, 0xff, 0x7f, 0x80}, assert.NoError},
+		{"0x prefix", "0x00ff7f80", []byte{0x00, 0xff, 0x7f, 0x80}, assert.NoError},
+		{"0X prefix", "0X00FF7F80", []byte{0x00, 0xff, 0x7f, 0x80}, assert.NoError},
+		{"odd length", "0x00f", nil, assert.Error},
+		{"invalid hex", "zz00", nil, assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hdwallet.EntropyFromString(tt.entropy)
+			if !tt.wantErr(t, err, fmt.Sprintf("EntropyFromString(%v)", tt.entropy)) || err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestHDWallet(t *testing.T) {
 	testOpts := [][]hdwallet.NewWalletOpt{
 		{hdwallet.WithMnemonic(testMnemonicA)},
@@ -287,6 +330,57 @@ func BenchmarkDeriveAll(b *testing.B) {
 	})
 }
 
+const benchmarkBulkAddresses int = 100_000
+
+// BenchmarkDeriveBulk compares deriving benchmarkBulkAddresses addresses using the
+// wallet's cached chain key against walking the full derivation path from the master key.
+func BenchmarkDeriveBulk(b *testing.B) {
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicA))
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for idx := 0; idx < benchmarkBulkAddresses; idx++ {
+				if _, err = w.DeriveAddressFromIndex(idx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("full-path", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicA))
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for idx := 0; idx < benchmarkBulkAddresses; idx++ {
+				path, err := accounts.ParseDerivationPath(fmt.Sprintf("m/44'/60'/0'/0/%d", idx))
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				key := w.MasterKey()
+				for _, n := range path {
+					if key, err = key.Derive(n); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				privKey, err := key.ECPrivKey()
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				_ = crypto.PubkeyToAddress(privKey.ToECDSA().PublicKey)
+			}
+		}
+	})
+}
+
 func benchmarkDeriveParallelNewWallet(b *testing.B, hardened bool) func(*testing.PB) {
 	w, err := hdwallet.NewHDWallet()
 	if err != nil {
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"math/big"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
 )
 
 var prefix0x = []byte{04} // "0x"
@@ -19,23 +21,67 @@ type HDWalletAddress struct {
 	address         common.Address
 	publicKey       ecdsa.PublicKey
 	privateKey      *ecdsa.PrivateKey
-	transactors     map[uint64]*bind.TransactOpts
+	vault           *vault         // nil unless the address belongs to a wallet
+	unrecorded      *walletAccount // set for addresses not kept by their wallet (see Iterate), whose private key is derived when needed
+	transactors     map[string]*bind.TransactOpts
+	nonces          *nonceManagers
+	coinType        CoinType
 	derivationIndex int
 	accountIndex    int
 	hardened        bool
+	imported        bool
 }
 
-func walletAddressFromPrivateKey(privKey *ecdsa.PrivateKey, accountIndex, derivationIndex int) *HDWalletAddress {
-	return newWalletAddress(
-		privKey,
-		privKey.PublicKey,
-		crypto.PubkeyToAddress(privKey.PublicKey),
-		accountIndex,
-		derivationIndex,
-	)
+// NewAddressFromPrivateKey returns an HDWalletAddress for a raw private key which wasn't
+// derived from an HD wallet's seed, such as a legacy single-key account.
+// The returned address is flagged as imported (see Imported), has no derivation path,
+// and can be attached to an HDWallet using HDWallet.ImportAddress.
+func NewAddressFromPrivateKey(privKey *ecdsa.PrivateKey) (*HDWalletAddress, error) {
+	if privKey == nil || privKey.D == nil {
+		return nil, errors.New("private key is nil")
+	}
+
+	if privKey.Curve != crypto.S256() {
+		return nil, errors.New("private key is not a secp256k1 key")
+	}
+
+	// round-trip the key to validate it and make sure it's on secp256k1.
+	validated, err := crypto.ToECDSA(crypto.FromECDSA(privKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid private key")
+	}
+
+	addr := newWalletAddress(validated, validated.PublicKey, crypto.PubkeyToAddress(validated.PublicKey), CoinTypeEthereum, 0, 0)
+	addr.imported = true
+
+	return addr, nil
+}
+
+// NewAddressFromPrivateKeyBytes is like NewAddressFromPrivateKey,
+// taking a 32-byte raw private key.
+func NewAddressFromPrivateKeyBytes(privKey []byte) (*HDWalletAddress, error) {
+	key, err := crypto.ToECDSA(privKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid private key")
+	}
+
+	return NewAddressFromPrivateKey(key)
+}
+
+// NewAddressFromPrivateKeyHex is like NewAddressFromPrivateKey,
+// taking a hex-encoded raw private key which may be prefixed with "0x".
+func NewAddressFromPrivateKeyHex(privKey string) (*HDWalletAddress, error) {
+	privKey = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(privKey), "0x"), "0X")
+
+	key, err := crypto.HexToECDSA(privKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid private key")
+	}
+
+	return NewAddressFromPrivateKey(key)
 }
 
-func newWalletAddress(privKey *ecdsa.PrivateKey, pubKey ecdsa.PublicKey, address common.Address, accountIdx, addressIdx int) *HDWalletAddress {
+func newWalletAddress(privKey *ecdsa.PrivateKey, pubKey ecdsa.PublicKey, address common.Address, coinType CoinType, accountIdx, addressIdx int) *HDWalletAddress {
 	hardened := isHardenedIdx(addressIdx)
 	if hardened {
 		addressIdx = getHardenedIdx(addressIdx)
@@ -45,6 +91,8 @@ func newWalletAddress(privKey *ecdsa.PrivateKey, pubKey ecdsa.PublicKey, address
 		address:         address,
 		privateKey:      privKey,
 		publicKey:       pubKey,
+		nonces:          new(nonceManagers),
+		coinType:        coinType,
 		derivationIndex: addressIdx,
 		accountIndex:    accountIdx,
 		hardened:        hardened,
@@ -55,19 +103,60 @@ func (a HDWalletAddress) Address() common.Address {
 	return a.address
 }
 
-func (a HDWalletAddress) PrivateKey() *ecdsa.PrivateKey {
-	return a.privateKey
+// withPrivateKey calls f with the address' private key, or returns ErrLocked
+// while the address' wallet is locked (see vault.withPrivateKey).
+func (a HDWalletAddress) withPrivateKey(f func(*ecdsa.PrivateKey) error) error {
+	if a.unrecorded == nil {
+		return a.vault.withPrivateKey(a.privateKey, f)
+	}
+
+	addressIdx := a.derivationIndex
+	if a.hardened {
+		addressIdx += hdkeychain.HardenedKeyStart
+	}
+
+	return a.unrecorded.withPrivateKey(addressIdx, f)
 }
 
-func (a HDWalletAddress) PrivateKeyHex() string {
-	return common.Bytes2Hex(a.PrivateKeyBytes())
+// PrivateKey returns a copy of the address' private key,
+// or ErrLocked while the address' wallet is locked.
+func (a HDWalletAddress) PrivateKey() (*ecdsa.PrivateKey, error) {
+	var privKey *ecdsa.PrivateKey
+
+	err := a.withPrivateKey(func(key *ecdsa.PrivateKey) error {
+		privKey = &ecdsa.PrivateKey{
+			PublicKey: key.PublicKey,
+			D:         new(big.Int).Set(key.D),
+		}
+
+		return nil
+	})
+
+	return privKey, err
 }
 
-func (a HDWalletAddress) PrivateKeyBytes() []byte {
-	return bytes.TrimPrefix(
-		crypto.FromECDSA(a.privateKey),
-		prefix0x,
-	)
+// PrivateKeyHex returns the hex-encoded private key,
+// or ErrLocked while the address' wallet is locked.
+func (a HDWalletAddress) PrivateKeyHex() (string, error) {
+	privKey, err := a.PrivateKeyBytes()
+	if err != nil {
+		return "", err
+	}
+
+	return common.Bytes2Hex(privKey), nil
+}
+
+// PrivateKeyBytes returns the 32-byte private key,
+// or ErrLocked while the address' wallet is locked.
+func (a HDWalletAddress) PrivateKeyBytes() ([]byte, error) {
+	var privKey []byte
+
+	err := a.withPrivateKey(func(key *ecdsa.PrivateKey) error {
+		privKey = crypto.FromECDSA(key)
+		return nil
+	})
+
+	return privKey, err
 }
 
 func (a HDWalletAddress) PublicKey() ecdsa.PublicKey {
@@ -85,23 +174,30 @@ func (a HDWalletAddress) PublicKeyBytes() []byte {
 	)
 }
 
-// TransactOptsForChainID wraps go-ethereum's low-level bind.NewKeyedTransactorWithChainID function,
-// returning a keyed *bind.TransactOpts object for the passed chainID.
+// TransactOptsForChainID returns a *bind.TransactOpts for the passed chainID
+// which signs transactions using the address (see NewTransactOpts).
 // If the HDWalletAddress instance has already successfully constructed a transactor for the
 // passed chainID, the previously constructed transactor is returned.
-// Otherwise, the result of bind.NewKeyedTransactorWithChainID is returned.
 func (a *HDWalletAddress) TransactOptsForChainID(chainID *big.Int) (*bind.TransactOpts, error) {
-	existingTransactor, ok := a.transactors[chainID.Uint64()]
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+
+	existingTransactor, ok := a.transactors[chainID.String()]
 	if ok {
 		return existingTransactor, nil
 	}
 
-	newTransactor, err := bind.NewKeyedTransactorWithChainID(a.privateKey, chainID)
+	newTransactor, err := NewTransactOpts(a, chainID)
 	if err != nil {
 		return nil, err
 	}
 
-	a.transactors[chainID.Uint64()] = newTransactor
+	if a.transactors == nil {
+		a.transactors = make(map[string]*bind.TransactOpts)
+	}
+
+	a.transactors[chainID.String()] = newTransactor
 
 	return newTransactor, nil
 }
@@ -118,10 +214,33 @@ func (a HDWalletAddress) HardenedDerivationIndex() int {
 	return a.derivationIndex
 }
 
+// DerivationPath returns the address' derivation path,
+// or an empty string if the address was imported or its account or derivation index is invalid.
 func (a HDWalletAddress) DerivationPath() string {
-	return addressDerivationPathFromIdx(a.accountIndex, a.HardenedDerivationIndex()).String()
+	if a.imported {
+		return ""
+	}
+
+	path, err := addressDerivationPathFromIdx(a.coinType, a.accountIndex, a.HardenedDerivationIndex())
+	if err != nil {
+		return ""
+	}
+
+	return path.String()
+}
+
+// CoinType returns the SLIP-44 coin type used in the address' derivation path.
+func (a HDWalletAddress) CoinType() CoinType {
+	return a.coinType
 }
 
 func (a HDWalletAddress) Hardened() bool {
 	return a.hardened
 }
+
+// Imported reports whether the address was created from a raw private key
+// using NewAddressFromPrivateKey, rather than derived from an HD wallet's seed.
+// Imported addresses are non-deterministic: they can't be restored from the wallet's mnemonic.
+func (a HDWalletAddress) Imported() bool {
+	return a.imported
+}
@@ -0,0 +1,216 @@
+// Package hdwallettest derives funded accounts from a fixed mnemonic and runs them on
+// a go-ethereum simulated backend, for tests of code using hdwallet.
+// By default it uses the accounts Hardhat and Anvil fund in their local networks.
+package hdwallettest
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+const (
+	// HardhatMnemonic is the mnemonic Hardhat and Anvil derive their default accounts from,
+	// the first being 0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266.
+	HardhatMnemonic string = "test test test test test test test test test test test junk"
+
+	// DefaultAccounts is the number of accounts derived by default, matching Hardhat and Anvil.
+	DefaultAccounts int = 10
+
+	// DefaultGasLimit is the default block gas limit of the simulated backend.
+	DefaultGasLimit uint64 = 30_000_000
+)
+
+// DefaultBalance returns the balance accounts are funded with by default,
+// 10000 ether as in Hardhat and Anvil.
+func DefaultBalance() *big.Int {
+	return new(big.Int).Mul(big.NewInt(10_000), big.NewInt(params.Ether))
+}
+
+// Env is a simulated backend along with the wallet whose accounts it funds.
+type Env struct {
+	Wallet  *hdwallet.HDWallet
+	Backend *backends.SimulatedBackend
+	ChainID *big.Int
+	Alloc   core.GenesisAlloc
+
+	// Accounts are the wallet's addresses at m/44'/60'/0'/0/i.
+	Accounts []*hdwallet.HDWalletAddress
+	// TransactOpts holds a *bind.TransactOpts for each of Accounts,
+	// which aren't shared with the addresses' own (see HDWalletAddress.TransactOptsForChainID).
+	TransactOpts []*bind.TransactOpts
+}
+
+type envOpts struct {
+	mnemonic string
+	accounts int
+	balance  *big.Int
+	gasLimit uint64
+	alloc    core.GenesisAlloc
+}
+
+type funcEnvOpt struct {
+	f func(*envOpts)
+}
+
+func newFuncEnvOpt(f func(*envOpts)) *funcEnvOpt {
+	return &funcEnvOpt{f}
+}
+
+func (fo *funcEnvOpt) apply(opts *envOpts) {
+	fo.f(opts)
+}
+
+type EnvOpt interface {
+	apply(*envOpts)
+}
+
+// WithMnemonic sets the mnemonic accounts are derived from.
+// Defaults to HardhatMnemonic.
+func WithMnemonic(mnemonic string) EnvOpt {
+	return newFuncEnvOpt(func(opts *envOpts) {
+		opts.mnemonic = mnemonic
+	})
+}
+
+// WithAccounts sets the number of accounts derived and funded.
+// Defaults to DefaultAccounts.
+func WithAccounts(n int) EnvOpt {
+	return newFuncEnvOpt(func(opts *envOpts) {
+		opts.accounts = n
+	})
+}
+
+// WithBalance sets the balance of every account in the genesis block.
+// Defaults to DefaultBalance.
+func WithBalance(balance *big.Int) EnvOpt {
+	return newFuncEnvOpt(func(opts *envOpts) {
+		opts.balance = balance
+	})
+}
+
+// WithGasLimit sets the simulated backend's block gas limit.
+// Defaults to DefaultGasLimit.
+func WithGasLimit(gasLimit uint64) EnvOpt {
+	return newFuncEnvOpt(func(opts *envOpts) {
+		opts.gasLimit = gasLimit
+	})
+}
+
+// WithAlloc adds genesis accounts, such as contracts or other funded addresses,
+// alongside the wallet's accounts. Its entries take precedence over the wallet's.
+func WithAlloc(alloc core.GenesisAlloc) EnvOpt {
+	return newFuncEnvOpt(func(opts *envOpts) {
+		opts.alloc = alloc
+	})
+}
+
+func defaultEnvOpts() *envOpts {
+	return &envOpts{
+		mnemonic: HardhatMnemonic,
+		accounts: DefaultAccounts,
+		balance:  DefaultBalance(),
+		gasLimit: DefaultGasLimit,
+	}
+}
+
+// Accounts returns the wallet for mnemonic along with its first n addresses,
+// at m/44'/60'/0'/0/0 to m/44'/60'/0'/0/n-1.
+func Accounts(mnemonic string, n int) (*hdwallet.HDWallet, []*hdwallet.HDWalletAddress, error) {
+	if n <= 0 {
+		return nil, nil, errors.Errorf("invalid number of accounts %d", n)
+	}
+
+	wallet, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(mnemonic))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating wallet")
+	}
+
+	addrs, err := wallet.DeriveRange(0, n)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error deriving accounts")
+	}
+
+	return wallet, addrs, nil
+}
+
+// GenesisAlloc returns a genesis allocation funding every address with balance.
+func GenesisAlloc(addrs []*hdwallet.HDWalletAddress, balance *big.Int) core.GenesisAlloc {
+	alloc := make(core.GenesisAlloc, len(addrs))
+
+	for _, addr := range addrs {
+		alloc[addr.Address()] = core.GenesisAccount{Balance: new(big.Int).Set(balance)}
+	}
+
+	return alloc
+}
+
+// New derives and funds accounts using any passed EnvOpt parameters,
+// and starts a simulated backend. Close the Env once done with it.
+func New(opts ...EnvOpt) (*Env, error) {
+	eOpts := defaultEnvOpts()
+	for _, o := range opts {
+		o.apply(eOpts)
+	}
+
+	if eOpts.balance == nil || eOpts.balance.Sign() < 0 {
+		return nil, errors.Errorf("invalid balance %v", eOpts.balance)
+	}
+
+	wallet, addrs, err := Accounts(eOpts.mnemonic, eOpts.accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	alloc := GenesisAlloc(addrs, eOpts.balance)
+	for address, account := range eOpts.alloc {
+		alloc[address] = account
+	}
+
+	backend := backends.NewSimulatedBackend(alloc, eOpts.gasLimit)
+	chainID := backend.Blockchain().Config().ChainID
+
+	transactOpts := make([]*bind.TransactOpts, len(addrs))
+
+	for i, addr := range addrs {
+		if transactOpts[i], err = hdwallet.NewTransactOpts(addr, chainID); err != nil {
+			_ = backend.Close()
+			return nil, errors.Wrap(err, "error creating transact opts")
+		}
+	}
+
+	return &Env{
+		Wallet:       wallet,
+		Backend:      backend,
+		ChainID:      new(big.Int).Set(chainID),
+		Alloc:        alloc,
+		Accounts:     addrs,
+		TransactOpts: transactOpts,
+	}, nil
+}
+
+// Start is like New, failing tb on errors and closing the Env when tb's test finishes.
+func Start(tb testing.TB, opts ...EnvOpt) *Env {
+	tb.Helper()
+
+	env, err := New(opts...)
+	if err != nil {
+		tb.Fatalf("error starting simulated backend: %v", err)
+	}
+
+	tb.Cleanup(func() { _ = env.Close() })
+
+	return env
+}
+
+// Close stops the simulated backend.
+func (e *Env) Close() error {
+	return e.Backend.Close()
+}
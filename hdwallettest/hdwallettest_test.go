@@ -0,0 +1,150 @@
+package hdwallettest_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go/hdwallettest"
+)
+
+func TestAccounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		n        int
+		want     []string
+		wantKey  string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name:     "hardhat",
+			mnemonic: hdwallettest.HardhatMnemonic,
+			n:        3,
+			want: []string{
+				"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+				"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+				"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+			},
+			wantKey: "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "no accounts",
+			mnemonic: hdwallettest.HardhatMnemonic,
+			n:        0,
+			wantErr:  assert.Error,
+		},
+		{
+			name:     "invalid mnemonic",
+			mnemonic: "test test test",
+			n:        1,
+			wantErr:  assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, addrs, err := hdwallettest.Accounts(tt.mnemonic, tt.n)
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			require.Len(t, addrs, len(tt.want))
+
+			for i, want := range tt.want {
+				assert.Equal(t, common.HexToAddress(want), addrs[i].Address())
+			}
+
+			privKey, err := addrs[0].PrivateKeyHex()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKey, privKey)
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	ctx := context.Background()
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000c0")
+
+	env := hdwallettest.Start(t,
+		hdwallettest.WithAccounts(3),
+		hdwallettest.WithAlloc(core.GenesisAlloc{contract: {Code: []byte{0x00}, Balance: big.NewInt(1)}}),
+	)
+
+	require.Len(t, env.Accounts, 3)
+	require.Len(t, env.TransactOpts, 3)
+	assert.Len(t, env.Alloc, 4)
+
+	for i, addr := range env.Accounts {
+		balance, err := env.Backend.BalanceAt(ctx, addr.Address(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, hdwallettest.DefaultBalance(), balance)
+
+		assert.Equal(t, addr.Address(), env.TransactOpts[i].From)
+	}
+
+	code, err := env.Backend.CodeAt(ctx, contract, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00}, code)
+
+	from, to := env.TransactOpts[0], env.Accounts[1].Address()
+	value := big.NewInt(1e18)
+
+	gasPrice, err := env.Backend.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+
+	tx, err := from.Signer(from.From, types.NewTx(&types.LegacyTx{
+		To:       &to,
+		Value:    value,
+		Gas:      21000,
+		GasPrice: gasPrice,
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, env.Backend.SendTransaction(ctx, tx))
+	env.Backend.Commit()
+
+	receipt, err := env.Backend.TransactionReceipt(ctx, tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	balance, err := env.Backend.BalanceAt(ctx, to, nil)
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).Add(hdwallettest.DefaultBalance(), value), balance)
+
+	sender, err := types.Sender(types.LatestSignerForChainID(env.ChainID), tx)
+	require.NoError(t, err)
+	assert.Equal(t, from.From, sender)
+}
+
+func TestNew(t *testing.T) {
+	env, err := hdwallettest.New(
+		hdwallettest.WithMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"),
+		hdwallettest.WithAccounts(1),
+		hdwallettest.WithBalance(big.NewInt(42)),
+		hdwallettest.WithGasLimit(10_000_000),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = env.Close() })
+
+	balance, err := env.Backend.BalanceAt(context.Background(), env.Accounts[0].Address(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(42), balance)
+
+	header, err := env.Backend.HeaderByNumber(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10_000_000), header.GasLimit)
+
+	_, err = hdwallettest.New(hdwallettest.WithBalance(big.NewInt(-1)))
+	assert.Error(t, err)
+
+	_, err = hdwallettest.New(hdwallettest.WithAccounts(-1))
+	assert.Error(t, err)
+}
@@ -1,25 +1,27 @@
 package hdwallet
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// MnemonicHasAddress reports whether the address with hex representation addressHex
+// is one of the first maxIndex non-hardened addresses of account 0 of the wallet
+// described by mnemonic and passphrase.
+// See SearchMnemonic for searching other accounts, hardened indices, or multiple addresses.
 func MnemonicHasAddress(addressHex, mnemonic, passphrase string, maxIndex int) (common.Address, bool, error) {
-	wallet, err := NewHDWallet(WithPassphrase(passphrase), WithMnemonic(mnemonic))
-	if err != nil {
-		return common.Address{}, false, err
-	}
+	res, found, err := SearchMnemonicForAddress(
+		context.Background(),
+		mnemonic,
+		passphrase,
+		common.HexToAddress(addressHex),
+		WithSearchIndices(0, maxIndex),
+	)
 
-	for i := 0; i < maxIndex; i++ {
-		newAddr, err := wallet.DeriveAddress()
-		if err != nil {
-			return common.Address{}, false, err
-		}
-
-		if addressEq(common.HexToAddress(addressHex), newAddr.address) {
-			return newAddr.Address(), true, nil
-		}
+	if err != nil || !found {
+		return common.Address{}, false, err
 	}
 
-	return common.Address{}, false, nil
+	return res.Address, true, nil
 }
@@ -0,0 +1,139 @@
+package hdwallet_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+const (
+	// hardhat's first development account, m/44'/60'/0'/0/0 of "test test ... junk".
+	testPrivateKeyHex  string = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	testPrivateKeyAddr string = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+)
+
+func TestNewAddressFromPrivateKey(t *testing.T) {
+	ecdsaKey, err := crypto.HexToECDSA(testPrivateKeyHex)
+	require.NoError(t, err)
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		newAddr func() (*hdwallet.HDWalletAddress, error)
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"hex", func() (*hdwallet.HDWalletAddress, error) {
+			return hdwallet.NewAddressFromPrivateKeyHex(testPrivateKeyHex)
+		}, assert.NoError},
+		{"0x hex", func() (*hdwallet.HDWalletAddress, error) {
+			return hdwallet.NewAddressFromPrivateKeyHex("0x" + testPrivateKeyHex)
+		}, assert.NoError},
+		{"bytes", func() (*hdwallet.HDWalletAddress, error) {
+			return hdwallet.NewAddressFromPrivateKeyBytes(common.FromHex(testPrivateKeyHex))
+		}, assert.NoError},
+		{"ecdsa", func() (*hdwallet.HDWalletAddress, error) { return hdwallet.NewAddressFromPrivateKey(ecdsaKey) }, assert.NoError},
+		{"invalid hex", func() (*hdwallet.HDWalletAddress, error) { return hdwallet.NewAddressFromPrivateKeyHex("zz") }, assert.Error},
+		{"short bytes", func() (*hdwallet.HDWalletAddress, error) {
+			return hdwallet.NewAddressFromPrivateKeyBytes([]byte{1, 2, 3})
+		}, assert.Error},
+		{"zero key", func() (*hdwallet.HDWalletAddress, error) {
+			return hdwallet.NewAddressFromPrivateKeyBytes(make([]byte, 32))
+		}, assert.Error},
+		{"nil key", func() (*hdwallet.HDWalletAddress, error) { return hdwallet.NewAddressFromPrivateKey(nil) }, assert.Error},
+		{"p256 key", func() (*hdwallet.HDWalletAddress, error) { return hdwallet.NewAddressFromPrivateKey(p256Key) }, assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.newAddr()
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			assert.Equal(t, common.HexToAddress(testPrivateKeyAddr), got.Address())
+			privKey, err := got.PrivateKeyHex()
+			require.NoError(t, err)
+
+			assert.Equal(t, testPrivateKeyHex, privKey)
+			assert.True(t, got.Imported())
+			assert.Empty(t, got.DerivationPath())
+		})
+	}
+}
+
+func TestHDWallet_ImportAddress(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	derived, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	assert.False(t, derived.Imported())
+
+	_, err = w.ImportAddress(derived)
+	assert.Error(t, err, "derived addresses can't be imported")
+
+	key, err := hdwallet.NewAddressFromPrivateKeyHex(testPrivateKeyHex)
+	require.NoError(t, err)
+
+	imported, err := w.ImportAddress(key)
+	require.NoError(t, err)
+	assert.Same(t, key, imported)
+
+	again, err := hdwallet.NewAddressFromPrivateKeyHex(testPrivateKeyHex)
+	require.NoError(t, err)
+
+	imported, err = w.ImportAddress(again)
+	require.NoError(t, err)
+	assert.Same(t, key, imported, "importing a key twice should return the first import")
+
+	assert.Equal(t, []*hdwallet.HDWalletAddress{derived, key}, w.Accounts())
+
+	found, ok := w.FindByAddress(key.Address())
+	assert.True(t, ok)
+	assert.Same(t, key, found)
+
+	records := w.AddressRecords()
+	require.Len(t, records, 2)
+	assert.False(t, records[0].Imported)
+	assert.True(t, records[1].Imported)
+	assert.Empty(t, records[1].DerivationPath)
+
+	secrets, err := w.ExportSecrets()
+	require.NoError(t, err)
+
+	assert.Equal(t, testPrivateKeyHex, secrets.Addresses[1].PrivateKey)
+
+	var buf bytes.Buffer
+	require.NoError(t, w.ExportAddressesCSV(&buf))
+
+	watchOnly, err := hdwallet.ImportAddressesCSV(&buf)
+	require.NoError(t, err)
+
+	watched, ok := watchOnly.FindByAddress(key.Address())
+	require.True(t, ok)
+	assert.True(t, watched.Imported())
+	assert.Empty(t, watched.DerivationPath())
+
+	// files exported before the imported column existed only hold derived addresses.
+	legacyCSV := "address,checksum_address,public_key,derivation_path,account,index,hardened\n" +
+		strings.ToLower(derived.Address().Hex()) + "," + derived.Address().Hex() + "," + derived.PublicKeyHex() + ",m/44'/60'/0'/0/0,0,0,false\n"
+
+	watchOnly, err = hdwallet.ImportAddressesCSV(strings.NewReader(legacyCSV))
+	require.NoError(t, err)
+
+	watched, ok = watchOnly.FindByPath(derived.DerivationPath())
+	require.True(t, ok)
+	assert.False(t, watched.Imported())
+}
@@ -0,0 +1,569 @@
+package hdwallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters used to derive the key encrypting locked wallets' secrets,
+// matching go-ethereum keystore's "light" parameters times 8.
+const (
+	lockScryptN     = 1 << 15
+	lockScryptR     = 8
+	lockScryptP     = 1
+	lockScryptDKLen = 32
+	lockSaltLen     = 32
+)
+
+var (
+	// ErrLocked is returned when accessing secrets of a locked wallet (see HDWallet.Lock).
+	ErrLocked = errors.New("wallet is locked")
+
+	// ErrWrongPassword is returned by HDWallet.Unlock when the password doesn't match.
+	ErrWrongPassword = errors.New("could not decrypt wallet secrets with the given password")
+
+	// ErrNotLockable is returned when locking or unlocking a wallet
+	// which wasn't created using WithLockPassword.
+	ErrNotLockable = errors.New("wallet has no lock password")
+)
+
+// Unlock decrypts the secrets of a wallet created using WithLockPassword, allowing its
+// addresses to sign and new addresses to be derived. The wallet is locked again after
+// duration, unless it's 0 in which case the wallet stays unlocked until Lock is called.
+// Unlocking an unlocked wallet replaces its previous duration.
+func (w *HDWallet) Unlock(password string, duration time.Duration) error {
+	return w.vault.unlock(password, duration)
+}
+
+// Lock wipes the decrypted secrets of a wallet created using WithLockPassword,
+// including the private keys of every address it keeps.
+// While locked, private key accessors and signing methods return ErrLocked,
+// and the wallet's Mnemonic, Seed, Entropy and MasterKey are empty.
+func (w *HDWallet) Lock() error {
+	return w.vault.manualLock()
+}
+
+// Locked reports whether the wallet is locked.
+func (w HDWallet) Locked() bool {
+	return w.vault.isLocked()
+}
+
+// vault holds a wallet's secrets, which are shared by the wallet and its addresses.
+// The plaintext secrets are kept in secure buffers (see secureBuffer), which are
+// released once the vault is garbage collected.
+// Wallets created using WithLockPassword also keep their secrets encrypted,
+// and wipe the plaintext secrets while locked.
+type vault struct {
+	mu sync.RWMutex
+
+	masterKey *hdkeychain.ExtendedKey // stored in masterBuf
+	chainKey  *hdkeychain.ExtendedKey // m/44'/coin_type'/account'/0, stored in chainBuf
+	masterBuf *secureBuffer
+	chainBuf  *secureBuffer
+	seed      *secureBuffer
+	entropy   *secureBuffer
+	mnemonic  *secureBuffer
+	keys      map[*ecdsa.PrivateKey]*secureBuffer
+
+	strictMemoryLock bool        // fail rather than use secure buffers which aren't locked into RAM
+	memoryNotLocked  atomic.Bool // some secure buffer isn't locked into RAM
+
+	lockable     bool
+	locked       bool
+	salt         []byte
+	aead         cipher.AEAD // only set while unlocked
+	sealed       []byte      // encrypted vaultSecrets
+	sealedKeys   map[*ecdsa.PrivateKey][]byte
+	lockTimer    *time.Timer
+	unlockNumber uint64 // identifies the latest Unlock, so stale timers don't lock the wallet
+}
+
+// newVault copies the passed secrets into a new vault.
+// If strictMemoryLock is set, allocating secure buffers fails with ErrMemoryNotLocked
+// rather than falling back to memory which isn't locked into RAM.
+func newVault(masterKey *hdkeychain.ExtendedKey, seed, entropy []byte, mnemonic string, strictMemoryLock bool) (*vault, error) {
+	v := &vault{
+		keys:             make(map[*ecdsa.PrivateKey]*secureBuffer),
+		strictMemoryLock: strictMemoryLock,
+	}
+
+	runtime.SetFinalizer(v, (*vault).destroy)
+
+	var err error
+
+	if v.masterBuf, err = v.newBuffer(extendedKeyLen); err != nil {
+		return nil, err
+	}
+
+	if v.chainBuf, err = v.newBuffer(extendedKeyLen); err != nil {
+		return nil, err
+	}
+
+	if v.masterKey, err = copyExtendedKey(v.masterBuf.bytes(), masterKey); err != nil {
+		return nil, err
+	}
+
+	if v.seed, err = v.newBufferFrom(seed); err != nil {
+		return nil, err
+	}
+
+	if v.entropy, err = v.newBufferFrom(entropy); err != nil {
+		return nil, err
+	}
+
+	mnemonicBytes := []byte(mnemonic)
+	defer wipeBytes(mnemonicBytes)
+
+	if v.mnemonic, err = v.newBufferFrom(mnemonicBytes); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// newBuffer allocates a secure buffer, recording whether it's locked into RAM.
+func (v *vault) newBuffer(size int) (*secureBuffer, error) {
+	buf, err := newSecureBuffer(size, v.strictMemoryLock)
+	if err != nil {
+		return nil, err
+	}
+
+	if !buf.locked {
+		v.memoryNotLocked.Store(true)
+	}
+
+	return buf, nil
+}
+
+// newBufferFrom copies src into a new secure buffer,
+// returning a nil buffer if src is empty.
+func (v *vault) newBufferFrom(src []byte) (*secureBuffer, error) {
+	if len(src) == 0 {
+		return nil, nil
+	}
+
+	buf, err := v.newBuffer(len(src))
+	if err != nil {
+		return nil, err
+	}
+
+	copy(buf.b, src)
+
+	return buf, nil
+}
+
+// setChainKey copies the key of the wallet's Ethereum address chain into the vault.
+func (v *vault) setChainKey(chainKey *hdkeychain.ExtendedKey) (err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.chainKey, err = copyExtendedKey(v.chainBuf.bytes(), chainKey)
+
+	return err
+}
+
+// enableLock encrypts the vault's secrets and registered keys using password, and locks it.
+// Keys registered afterwards are encrypted as they're registered.
+func (v *vault) enableLock(password string) error {
+	salt := make([]byte, lockSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return errors.Wrap(err, "error generating salt")
+	}
+
+	aead, err := newLockAEAD(password, salt)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.lockable = true
+	v.salt = salt
+	v.aead = aead
+	v.sealedKeys = make(map[*ecdsa.PrivateKey][]byte, len(v.keys))
+
+	if v.sealed, err = v.sealSecrets(); err != nil {
+		return err
+	}
+
+	for key := range v.keys {
+		if err := v.sealKey(key); err != nil {
+			return err
+		}
+	}
+
+	v.lock()
+
+	return nil
+}
+
+// register moves key into secure memory owned by the vault, and encrypts it so it can be
+// wiped while the vault is locked. Registering keys of a locked vault fails,
+// as they can't be encrypted.
+func (v *vault) register(key *ecdsa.PrivateKey) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.keys[key]; ok {
+		return nil
+	}
+
+	if v.locked {
+		return ErrLocked
+	}
+
+	if v.lockable {
+		if err := v.sealKey(key); err != nil {
+			return err
+		}
+	}
+
+	buf, err := v.newBuffer(privateKeyLen)
+	if err != nil {
+		return err
+	}
+
+	moveToSecureBuffer(key, buf)
+	v.keys[key] = buf
+
+	return nil
+}
+
+func (v *vault) sealKey(key *ecdsa.PrivateKey) error {
+	if _, ok := v.sealedKeys[key]; ok {
+		return nil
+	}
+
+	d, err := v.newBuffer(privateKeyLen)
+	if err != nil {
+		return err
+	}
+
+	defer d.destroy()
+
+	key.D.FillBytes(d.bytes())
+
+	sealed, err := v.seal(d.bytes())
+	if err != nil {
+		return err
+	}
+
+	v.sealedKeys[key] = sealed
+
+	return nil
+}
+
+// secretBuffers returns the buffers whose contents are sealed together, in order.
+// Their lengths never change, so the plaintext doesn't need to encode them.
+func (v *vault) secretBuffers() []*secureBuffer {
+	return []*secureBuffer{v.masterBuf, v.chainBuf, v.seed, v.entropy, v.mnemonic}
+}
+
+func (v *vault) secretsLen() int {
+	n := 0
+	for _, buf := range v.secretBuffers() {
+		n += len(buf.bytes())
+	}
+
+	return n
+}
+
+// sealSecrets encrypts the concatenated contents of the vault's secret buffers.
+func (v *vault) sealSecrets() ([]byte, error) {
+	plaintext, err := v.newBuffer(v.secretsLen())
+	if err != nil {
+		return nil, err
+	}
+
+	defer plaintext.destroy()
+
+	off := 0
+	for _, buf := range v.secretBuffers() {
+		off += copy(plaintext.bytes()[off:], buf.bytes())
+	}
+
+	return v.seal(plaintext.bytes())
+}
+
+func (v *vault) seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "error generating nonce")
+	}
+
+	return v.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts sealed in place into dst, which must be as long as the plaintext.
+func open(aead cipher.AEAD, sealed, dst []byte) error {
+	if len(sealed) != aead.NonceSize()+len(dst)+aead.Overhead() {
+		return ErrWrongPassword
+	}
+
+	if _, err := aead.Open(dst[:0], sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil); err != nil {
+		return ErrWrongPassword
+	}
+
+	return nil
+}
+
+// unlock decrypts the vault's secrets, locking it again after duration unless it's 0.
+func (v *vault) unlock(password string, duration time.Duration) error {
+	v.mu.RLock()
+	lockable, salt, sealed, secretsLen := v.lockable, v.salt, v.sealed, v.secretsLen()
+	v.mu.RUnlock()
+
+	if !lockable {
+		return ErrNotLockable
+	}
+
+	// key derivation is slow, so avoid blocking readers while it runs.
+	aead, err := newLockAEAD(password, salt)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := v.newBuffer(secretsLen)
+	if err != nil {
+		return err
+	}
+
+	defer plaintext.destroy()
+
+	if err := open(aead, sealed, plaintext.bytes()); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.locked {
+		if err := v.restore(aead, plaintext.bytes()); err != nil {
+			v.lock()
+			return err
+		}
+	}
+
+	v.aead = aead
+	v.locked = false
+	v.unlockNumber++
+
+	if v.lockTimer != nil {
+		v.lockTimer.Stop()
+		v.lockTimer = nil
+	}
+
+	if duration > 0 {
+		unlockNumber := v.unlockNumber
+		v.lockTimer = time.AfterFunc(duration, func() {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+
+			if v.unlockNumber == unlockNumber {
+				v.lock()
+			}
+		})
+	}
+
+	return nil
+}
+
+// restore copies the decrypted secrets back into the vault's secure buffers,
+// which the vault's master and chain keys are backed by, and decrypts every registered key.
+func (v *vault) restore(aead cipher.AEAD, plaintext []byte) error {
+	off := 0
+	for _, buf := range v.secretBuffers() {
+		off += copy(buf.bytes(), plaintext[off:])
+	}
+
+	d, err := v.newBuffer(privateKeyLen)
+	if err != nil {
+		return err
+	}
+
+	defer d.destroy()
+
+	for key, sealed := range v.sealedKeys {
+		if err := open(aead, sealed, d.bytes()); err != nil {
+			return err
+		}
+
+		key.D.SetBytes(d.bytes())
+	}
+
+	return nil
+}
+
+// lock wipes the vault's plaintext secrets. v.mu must be held for writing.
+func (v *vault) lock() {
+	v.masterBuf.wipe()
+	v.chainBuf.wipe()
+	v.seed.wipe()
+	v.entropy.wipe()
+	v.mnemonic.wipe()
+
+	for key := range v.keys {
+		wipeBigInt(key)
+	}
+
+	v.aead = nil
+	v.locked = true
+
+	if v.lockTimer != nil {
+		v.lockTimer.Stop()
+		v.lockTimer = nil
+	}
+}
+
+// destroy releases the vault's secure buffers once it's garbage collected.
+func (v *vault) destroy() {
+	v.masterBuf.destroy()
+	v.chainBuf.destroy()
+	v.seed.destroy()
+	v.entropy.destroy()
+	v.mnemonic.destroy()
+
+	for _, buf := range v.keys {
+		buf.destroy()
+	}
+}
+
+// manualLock locks the vault on behalf of HDWallet.Lock.
+func (v *vault) manualLock() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.lockable {
+		return ErrNotLockable
+	}
+
+	v.lock()
+
+	return nil
+}
+
+func (v *vault) isLocked() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.locked
+}
+
+// withMaster calls f with the wallet's master key while holding the vault's read lock,
+// or returns ErrLocked. The key must not be used once f returns.
+func (v *vault) withMaster(f func(*hdkeychain.ExtendedKey) error) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.locked {
+		return ErrLocked
+	}
+
+	return f(v.masterKey)
+}
+
+// withChain calls f with the key of the wallet's Ethereum address chain
+// while holding the vault's read lock, or returns ErrLocked.
+// The key must not be used once f returns.
+func (v *vault) withChain(f func(*hdkeychain.ExtendedKey) error) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.locked {
+		return ErrLocked
+	}
+
+	return f(v.chainKey)
+}
+
+// secret returns a copy of buf's contents, or nil while the vault is locked.
+func (v *vault) secret(buf *secureBuffer) []byte {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.locked || buf == nil {
+		return nil
+	}
+
+	return append([]byte(nil), buf.bytes()...)
+}
+
+// withPrivateKey calls f with key while holding the vault's read lock,
+// or returns ErrLocked. v may be nil for addresses which don't belong to a wallet.
+func (v *vault) withPrivateKey(key *ecdsa.PrivateKey, f func(*ecdsa.PrivateKey) error) error {
+	if v != nil {
+		v.mu.RLock()
+		defer v.mu.RUnlock()
+
+		if v.locked {
+			return ErrLocked
+		}
+	}
+
+	if key == nil {
+		return errors.New("address has no private key")
+	}
+
+	return f(key)
+}
+
+func newLockAEAD(password string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), salt, lockScryptN, lockScryptR, lockScryptP, lockScryptDKLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "error deriving encryption key")
+	}
+
+	defer wipeBytes(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating cipher")
+	}
+
+	return aead, nil
+}
+
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// wipeString zeroes the memory of a string built at runtime.
+// It must never be called with string constants, which live in read-only memory,
+// nor with strings which are still in use, as strings are assumed to be immutable.
+func wipeString(s string) {
+	if s == "" {
+		return
+	}
+
+	wipeBytes(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// wipeBigInt zeroes key's private scalar in place, as go-ethereum's keystore does.
+func wipeBigInt(key *ecdsa.PrivateKey) {
+	words := key.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+
+	key.D.SetInt64(0)
+}
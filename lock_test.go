@@ -0,0 +1,364 @@
+package hdwallet_test
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+const testLockPassword string = "correct horse battery staple"
+
+func newLockedWallet(t *testing.T, opts ...hdwallet.NewWalletOpt) *hdwallet.HDWallet {
+	t.Helper()
+
+	w, err := hdwallet.NewHDWallet(append([]hdwallet.NewWalletOpt{
+		hdwallet.WithMnemonic(testMnemonicZero),
+		hdwallet.WithLockPassword(testLockPassword),
+	}, opts...)...)
+	require.NoError(t, err)
+
+	return w
+}
+
+// assertLocked checks that every secret of w and addr is inaccessible.
+func assertLocked(t *testing.T, w *hdwallet.HDWallet, addr *hdwallet.HDWalletAddress) {
+	t.Helper()
+
+	assert.True(t, w.Locked())
+	assert.Empty(t, w.Mnemonic())
+	assert.Empty(t, w.Seed())
+	assert.Empty(t, w.Entropy())
+	assert.Nil(t, w.MasterKey())
+
+	_, err := w.ExportSecrets()
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = w.DeriveExtendedKey("m/44'/60'/0'")
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = w.DeriveAddressFromIndex(1000)
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = w.DeriveBitcoinAddress(hdwallet.BitcoinP2WPKH, 0, false, 0)
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = addr.PrivateKey()
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = addr.PrivateKeyHex()
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = addr.PrivateKeyBytes()
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = addr.ExportSecrets()
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = addr.SignHash(crypto.Keccak256(nil))
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	_, err = addr.SignTx(types.NewTx(&types.LegacyTx{To: &to, Gas: 21000, GasPrice: big.NewInt(1)}), big.NewInt(1))
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+}
+
+func TestHDWallet_Lock(t *testing.T) {
+	plain, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	want, err := plain.DeriveAddressFromIndex(3)
+	require.NoError(t, err)
+
+	wantSecrets, err := plain.ExportSecrets()
+	require.NoError(t, err)
+
+	w := newLockedWallet(t)
+
+	assert.True(t, w.Locked(), "wallets with a lock password start locked")
+
+	_, err = w.DeriveAddressFromIndex(3)
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	assert.ErrorIs(t, w.Unlock("wrong password", 0), hdwallet.ErrWrongPassword)
+	assert.True(t, w.Locked())
+
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+	assert.False(t, w.Locked())
+
+	addr, err := w.DeriveAddressFromIndex(3)
+	require.NoError(t, err)
+
+	assert.Equal(t, want.Address(), addr.Address())
+
+	secrets, err := w.ExportSecrets()
+	require.NoError(t, err)
+	assert.Equal(t, wantSecrets, secrets)
+
+	require.NoError(t, w.Lock())
+	assertLocked(t, w, addr)
+
+	// public data stays available while locked
+	assert.Equal(t, []*hdwallet.HDWalletAddress{addr}, w.Accounts())
+	assert.Equal(t, want.PublicKeyHex(), addr.PublicKeyHex())
+
+	found, ok := w.FindByAddress(want.Address())
+	require.True(t, ok)
+	assert.Same(t, addr, found)
+
+	again, err := w.DeriveAddressFromIndex(3)
+	require.NoError(t, err, "already derived addresses don't need to be derived again")
+	assert.Same(t, addr, again)
+
+	// unlocking restores every secret
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	secrets, err = w.ExportSecrets()
+	require.NoError(t, err)
+	assert.Equal(t, wantSecrets, secrets)
+
+	privKey, err := addr.PrivateKeyHex()
+	require.NoError(t, err)
+
+	wantPrivKey, err := want.PrivateKeyHex()
+	require.NoError(t, err)
+	assert.Equal(t, wantPrivKey, privKey)
+
+	hash := crypto.Keccak256([]byte("unlocked"))
+
+	sig, err := addr.SignHash(hash)
+	require.NoError(t, err)
+
+	pubKey, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	assert.Equal(t, addr.Address(), crypto.PubkeyToAddress(*pubKey))
+}
+
+func TestHDWallet_Lock_PrivateKeyCopies(t *testing.T) {
+	w := newLockedWallet(t)
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	addr, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	privKey, err := addr.PrivateKey()
+	require.NoError(t, err)
+
+	want := new(big.Int).Set(privKey.D)
+
+	require.NoError(t, w.Lock())
+
+	// keys returned while unlocked belong to the caller, and aren't wiped
+	assert.Equal(t, want, privKey.D)
+}
+
+func TestHDWallet_Lock_BitcoinAddress(t *testing.T) {
+	w := newLockedWallet(t)
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	addr, err := w.DeriveBitcoinAddress(hdwallet.BitcoinP2WPKH, 0, false, 0)
+	require.NoError(t, err)
+
+	wantPrivKey, err := addr.PrivateKey()
+	require.NoError(t, err)
+
+	wantWIF, err := addr.WIF()
+	require.NoError(t, err)
+
+	require.NoError(t, w.Lock())
+
+	_, err = addr.PrivateKey()
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	_, err = addr.WIF()
+	assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	privKey, err := addr.PrivateKey()
+	require.NoError(t, err)
+	assert.Equal(t, wantPrivKey.Serialize(), privKey.Serialize())
+
+	wif, err := addr.WIF()
+	require.NoError(t, err)
+	assert.Equal(t, wantWIF, wif)
+}
+
+func TestHDWallet_Lock_IteratedAddress(t *testing.T) {
+	w := newLockedWallet(t)
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	hardenedIdx := 1<<31 + 5
+
+	for _, idx := range []int{3, hardenedIdx} {
+		it := w.Iterate(idx, idx+1)
+		require.True(t, it.Next())
+		require.NoError(t, it.Err())
+
+		addr := it.Address()
+
+		wantPrivKey, err := addr.PrivateKeyHex()
+		require.NoError(t, err)
+
+		require.NoError(t, w.Lock())
+
+		// iterated addresses aren't kept by the wallet, but are locked along with it
+		_, err = addr.PrivateKey()
+		assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+		_, err = addr.SignHash(crypto.Keccak256(nil))
+		assert.ErrorIs(t, err, hdwallet.ErrLocked)
+
+		require.NoError(t, w.Unlock(testLockPassword, 0))
+
+		privKey, err := addr.PrivateKeyHex()
+		require.NoError(t, err)
+		assert.Equal(t, wantPrivKey, privKey)
+
+		sig, err := addr.SignHash(crypto.Keccak256(nil))
+		require.NoError(t, err)
+
+		pubKey, err := crypto.SigToPub(crypto.Keccak256(nil), sig)
+		require.NoError(t, err)
+		assert.Equal(t, addr.Address(), crypto.PubkeyToAddress(*pubKey))
+
+		recorded, err := w.DeriveAddressFromIndex(idx)
+		require.NoError(t, err)
+		assert.Equal(t, recorded.Address(), addr.Address())
+
+		recordedPrivKey, err := recorded.PrivateKeyHex()
+		require.NoError(t, err)
+		assert.Equal(t, recordedPrivKey, privKey)
+	}
+}
+
+func TestHDWallet_Lock_MasterKey(t *testing.T) {
+	plain, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	masterKey, err := hdwallet.ParseExtendedKey(plain.MasterKey().String())
+	require.NoError(t, err)
+
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMasterKey(masterKey), hdwallet.WithLockPassword(testLockPassword))
+	require.NoError(t, err)
+
+	assert.Nil(t, w.MasterKey())
+
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+	assert.Equal(t, plain.MasterKey().String(), w.MasterKey().String())
+	assert.Empty(t, w.Mnemonic())
+}
+
+func TestHDWallet_Unlock_Duration(t *testing.T) {
+	w := newLockedWallet(t)
+
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	addr, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	require.NoError(t, w.Unlock(testLockPassword, 50*time.Millisecond))
+	assert.False(t, w.Locked())
+
+	require.Eventually(t, w.Locked, 5*time.Second, 10*time.Millisecond)
+	assertLocked(t, w, addr)
+
+	// unlocking again replaces the previous duration
+	require.NoError(t, w.Unlock(testLockPassword, 50*time.Millisecond))
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	time.Sleep(150 * time.Millisecond)
+	assert.False(t, w.Locked())
+
+	// as does locking manually
+	require.NoError(t, w.Unlock(testLockPassword, 50*time.Millisecond))
+	require.NoError(t, w.Lock())
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	time.Sleep(150 * time.Millisecond)
+	assert.False(t, w.Locked())
+}
+
+func TestHDWallet_Lock_NotLockable(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	assert.False(t, w.Locked())
+	assert.ErrorIs(t, w.Lock(), hdwallet.ErrNotLockable)
+	assert.ErrorIs(t, w.Unlock(testLockPassword, 0), hdwallet.ErrNotLockable)
+}
+
+func TestHDWallet_Lock_ImportAddress(t *testing.T) {
+	w := newLockedWallet(t)
+
+	imported, err := hdwallet.NewAddressFromPrivateKeyHex(testPrivateKeyHex)
+	require.NoError(t, err)
+
+	_, err = w.ImportAddress(imported)
+	assert.ErrorIs(t, err, hdwallet.ErrLocked, "keys can't be encrypted while locked")
+
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	imported, err = w.ImportAddress(imported)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Lock())
+	assertLocked(t, w, imported)
+
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	privKey, err := imported.PrivateKeyHex()
+	require.NoError(t, err)
+	assert.Equal(t, testPrivateKeyHex, privKey)
+}
+
+func TestHDWallet_Lock_Concurrent(t *testing.T) {
+	w := newLockedWallet(t)
+	require.NoError(t, w.Unlock(testLockPassword, 0))
+
+	addrs, err := w.DeriveRange(0, 4)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for _, addr := range addrs {
+		wg.Add(1)
+
+		go func(addr *hdwallet.HDWalletAddress) {
+			defer wg.Done()
+
+			hash := crypto.Keccak256(addr.Address().Bytes())
+
+			for i := 0; i < 50; i++ {
+				sig, err := addr.SignHash(hash)
+				if err != nil {
+					assert.ErrorIs(t, err, hdwallet.ErrLocked)
+					continue
+				}
+
+				pubKey, err := crypto.SigToPub(hash, sig)
+				if assert.NoError(t, err) {
+					assert.Equal(t, addr.Address(), crypto.PubkeyToAddress(*pubKey))
+				}
+
+				_ = w.Mnemonic()
+			}
+		}(addr)
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.Lock())
+		require.NoError(t, w.Unlock(testLockPassword, time.Millisecond))
+	}
+
+	wg.Wait()
+}
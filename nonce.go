@@ -0,0 +1,225 @@
+package hdwallet
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// NonceBackend is the part of bind.ContractBackend used by NonceManager.
+type NonceBackend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out the nonces of an address' transactions on a single chain,
+// so concurrent transactions from the address never share a nonce.
+// It syncs with the backend's pending nonce the first time a nonce is needed,
+// and afterwards keeps track of the nonces it handed out itself.
+type NonceManager struct {
+	mu       sync.Mutex
+	address  common.Address
+	backend  NonceBackend
+	synced   bool
+	next     uint64
+	released []uint64 // sorted nonces below next which were handed out, then released
+}
+
+// NewNonceManager returns a NonceManager for address, syncing with backend.
+// Use a single NonceManager per address and chain.
+func NewNonceManager(address common.Address, backend NonceBackend) (*NonceManager, error) {
+	if backend == nil {
+		return nil, errors.New("backend must not be nil")
+	}
+
+	return &NonceManager{
+		address: address,
+		backend: backend,
+	}, nil
+}
+
+// Address returns the address whose nonces are managed.
+func (m *NonceManager) Address() common.Address {
+	return m.address
+}
+
+// Next returns the next nonce to use, which won't be returned again unless it's released.
+// Released nonces are reused first, lowest first, so gaps are filled before new nonces are used.
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced {
+		if err := m.sync(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(m.released) > 0 {
+		nonce := m.released[0]
+		m.released = m.released[1:]
+
+		return nonce, nil
+	}
+
+	nonce := m.next
+	m.next++
+
+	return nonce, nil
+}
+
+// Release returns a nonce handed out by Next whose transaction was never sent,
+// for example because signing or sending it failed, so it's handed out again.
+// Releasing a nonce which wasn't handed out, or which was already released, does nothing.
+func (m *NonceManager) Release(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced || nonce >= m.next {
+		return
+	}
+
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= nonce })
+	if i < len(m.released) && m.released[i] == nonce {
+		return
+	}
+
+	m.released = append(m.released, 0)
+	copy(m.released[i+1:], m.released[i:])
+	m.released[i] = nonce
+
+	// released nonces at the end of the range don't need to be kept around.
+	for len(m.released) > 0 && m.released[len(m.released)-1] == m.next-1 {
+		m.released = m.released[:len(m.released)-1]
+		m.next--
+	}
+}
+
+// Sync fetches the address' pending nonce from the backend, skipping ahead to it if
+// transactions were sent without using the NonceManager. Nonces handed out
+// which the backend doesn't know about yet are kept.
+func (m *NonceManager) Sync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.sync(ctx)
+}
+
+// Reset forgets every nonce handed out or released, so the next nonce is synced
+// from the backend's pending nonce. Use it when transactions were dropped or
+// replaced, or the backend rejected a nonce as too low.
+func (m *NonceManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.synced = false
+	m.next = 0
+	m.released = nil
+}
+
+func (m *NonceManager) sync(ctx context.Context) error {
+	pending, err := m.backend.PendingNonceAt(ctx, m.address)
+	if err != nil {
+		return errors.Wrapf(err, "error fetching pending nonce of %s", m.address.Hex())
+	}
+
+	if !m.synced || pending > m.next {
+		m.next = pending
+	}
+
+	m.synced = true
+
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= pending })
+	m.released = m.released[i:]
+
+	return nil
+}
+
+// Transact calls send with a copy of opts using the next nonce, releasing the nonce
+// if send fails. send must only fail if its transaction wasn't sent, like the methods
+// of bind.BoundContract and abigen's bindings.
+func (m *NonceManager) Transact(ctx context.Context, opts *bind.TransactOpts, send func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	if opts == nil {
+		return nil, errors.New("transact opts must not be nil")
+	}
+
+	if opts.From != m.address {
+		return nil, bind.ErrNotAuthorized
+	}
+
+	nonce, err := m.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	withNonce := *opts
+	withNonce.Nonce = new(big.Int).SetUint64(nonce)
+	withNonce.Context = ctx
+
+	tx, err := send(&withNonce)
+	if err != nil {
+		m.Release(nonce)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// nonceManagers holds an address' NonceManager for each chain, by decimal chain ID.
+type nonceManagers struct {
+	mu       sync.Mutex
+	managers map[string]*NonceManager
+}
+
+// nonceManagersInitMu guards the lazy creation of HDWalletAddress.nonces
+// for addresses which weren't created by newWalletAddress, such as zero values.
+var nonceManagersInitMu sync.Mutex
+
+func (a *HDWalletAddress) nonceManagers() *nonceManagers {
+	nonceManagersInitMu.Lock()
+	defer nonceManagersInitMu.Unlock()
+
+	if a.nonces == nil {
+		a.nonces = new(nonceManagers)
+	}
+
+	return a.nonces
+}
+
+// NonceManagerForChainID returns the address' NonceManager for the passed chainID,
+// creating it using backend the first time it's requested for chainID.
+// Later calls for chainID return that same NonceManager, which keeps using the backend
+// it was created with: backend is ignored, and may be nil, once the manager exists.
+// Use NewNonceManager for a manager using a different backend.
+func (a *HDWalletAddress) NonceManagerForChainID(chainID *big.Int, backend NonceBackend) (*NonceManager, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+
+	nonces := a.nonceManagers()
+
+	nonces.mu.Lock()
+	defer nonces.mu.Unlock()
+
+	if existing, ok := nonces.managers[chainID.String()]; ok {
+		return existing, nil
+	}
+
+	manager, err := NewNonceManager(a.address, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if nonces.managers == nil {
+		nonces.managers = make(map[string]*NonceManager)
+	}
+
+	nonces.managers[chainID.String()] = manager
+
+	return manager, nil
+}
@@ -0,0 +1,304 @@
+package hdwallet_test
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/hdwallettest"
+)
+
+var (
+	simulatedChainID = big.NewInt(1337)
+	nonceTestTo      = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+)
+
+func newSimulatedAddress(t *testing.T) (*hdwallet.HDWalletAddress, *backends.SimulatedBackend) {
+	t.Helper()
+
+	env := hdwallettest.Start(t, hdwallettest.WithMnemonic(testMnemonicZero), hdwallettest.WithAccounts(1))
+	require.Equal(t, simulatedChainID, env.ChainID)
+
+	return env.Accounts[0], env.Backend
+}
+
+// sendTransfer signs and sends a transfer from addr using nonce.
+func sendTransfer(ctx context.Context, backend bind.ContractTransactor, addr *hdwallet.HDWalletAddress, nonce uint64) (*types.Transaction, error) {
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := addr.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &nonceTestTo,
+		Value:    big.NewInt(1),
+		Gas:      21000,
+		GasPrice: gasPrice,
+	}), simulatedChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, backend.SendTransaction(ctx, tx)
+}
+
+func TestNonceManager_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	addr, backend := newSimulatedAddress(t)
+
+	manager, err := addr.NonceManagerForChainID(simulatedChainID, backend)
+	require.NoError(t, err)
+
+	const n = 50
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		nonces []uint64
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			nonce, err := manager.Next(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			mu.Lock()
+			nonces = append(nonces, nonce)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+
+	require.Len(t, nonces, n)
+
+	for i, nonce := range nonces {
+		require.Equal(t, uint64(i), nonce, "nonces are handed out exactly once")
+
+		// the simulated backend only accepts transactions in nonce order
+		_, err := sendTransfer(ctx, backend, addr, nonce)
+		require.NoError(t, err)
+	}
+
+	backend.Commit()
+
+	onChain, err := backend.NonceAt(ctx, addr.Address(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(n), onChain)
+}
+
+func TestNonceManager_Release(t *testing.T) {
+	ctx := context.Background()
+	addr, backend := newSimulatedAddress(t)
+
+	manager, err := hdwallet.NewNonceManager(addr.Address(), backend)
+	require.NoError(t, err)
+
+	next := func() uint64 {
+		nonce, err := manager.Next(ctx)
+		require.NoError(t, err)
+
+		return nonce
+	}
+
+	for i := uint64(0); i < 5; i++ {
+		require.Equal(t, i, next())
+	}
+
+	manager.Release(3)
+	manager.Release(1)
+	manager.Release(1)
+	manager.Release(10) // never handed out
+
+	assert.Equal(t, uint64(1), next(), "lowest released nonce first")
+	assert.Equal(t, uint64(3), next())
+	assert.Equal(t, uint64(5), next())
+
+	// releasing the latest nonces rewinds instead of leaving gaps
+	manager.Release(4)
+	manager.Release(5)
+	manager.Release(3)
+
+	assert.Equal(t, uint64(3), next())
+	assert.Equal(t, uint64(4), next())
+	assert.Equal(t, uint64(5), next())
+}
+
+func TestNonceManager_SyncAndReset(t *testing.T) {
+	ctx := context.Background()
+	addr, backend := newSimulatedAddress(t)
+
+	manager, err := addr.NonceManagerForChainID(simulatedChainID, backend)
+	require.NoError(t, err)
+
+	nonce, err := manager.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), nonce)
+
+	_, err = sendTransfer(ctx, backend, addr, nonce)
+	require.NoError(t, err)
+
+	// transactions sent without the manager
+	for i := uint64(1); i <= 3; i++ {
+		_, err = sendTransfer(ctx, backend, addr, i)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, manager.Sync(ctx))
+
+	nonce, err = manager.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), nonce, "syncing skips ahead to the pending nonce")
+
+	for i := 0; i < 3; i++ {
+		_, err = manager.Next(ctx)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, manager.Sync(ctx))
+
+	nonce, err = manager.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), nonce, "syncing keeps nonces the backend doesn't know about yet")
+
+	// nonces 4 to 8 were never sent
+	manager.Reset()
+
+	nonce, err = manager.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), nonce, "resetting syncs with the backend again")
+
+	backend.Commit()
+
+	_, err = sendTransfer(ctx, backend, addr, nonce)
+	require.NoError(t, err)
+}
+
+func TestNonceManager_Transact(t *testing.T) {
+	ctx := context.Background()
+	addr, backend := newSimulatedAddress(t)
+
+	manager, err := addr.NonceManagerForChainID(simulatedChainID, backend)
+	require.NoError(t, err)
+
+	opts, err := addr.TransactOptsForChainID(simulatedChainID)
+	require.NoError(t, err)
+
+	send := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return sendTransfer(opts.Context, backend, addr, opts.Nonce.Uint64())
+	}
+
+	tx, err := manager.Transact(ctx, opts, send)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), tx.Nonce())
+	assert.Nil(t, opts.Nonce, "opts isn't modified")
+
+	sendErr := errors.New("send failed")
+
+	_, err = manager.Transact(ctx, opts, func(*bind.TransactOpts) (*types.Transaction, error) {
+		return nil, sendErr
+	})
+	assert.ErrorIs(t, err, sendErr)
+
+	tx, err = manager.Transact(ctx, opts, send)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), tx.Nonce(), "failed transactions release their nonce")
+
+	backend.Commit()
+
+	onChain, err := backend.NonceAt(ctx, addr.Address(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), onChain)
+
+	_, err = manager.Transact(ctx, &bind.TransactOpts{From: nonceTestTo}, send)
+	assert.ErrorIs(t, err, bind.ErrNotAuthorized)
+}
+
+type failingNonceBackend struct{}
+
+func (failingNonceBackend) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return 0, errors.New("backend unavailable")
+}
+
+func TestHDWalletAddress_NonceManagerForChainID(t *testing.T) {
+	addr, backend := newSimulatedAddress(t)
+
+	manager, err := addr.NonceManagerForChainID(simulatedChainID, backend)
+	require.NoError(t, err)
+	assert.Equal(t, addr.Address(), manager.Address())
+
+	again, err := addr.NonceManagerForChainID(big.NewInt(1337), backend)
+	require.NoError(t, err)
+	assert.Same(t, manager, again)
+
+	other, err := addr.NonceManagerForChainID(big.NewInt(1), failingNonceBackend{})
+	require.NoError(t, err)
+	assert.NotSame(t, manager, other)
+
+	_, err = other.Next(context.Background())
+	assert.ErrorContains(t, err, "backend unavailable")
+
+	// the backend is only used to create the manager
+	again, err = addr.NonceManagerForChainID(big.NewInt(1), backend)
+	require.NoError(t, err)
+	assert.Same(t, other, again)
+
+	// chain IDs which don't fit in a uint64 aren't truncated
+	large, err := addr.NonceManagerForChainID(new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)), backend)
+	require.NoError(t, err)
+	assert.NotSame(t, other, large)
+
+	_, err = addr.NonceManagerForChainID(nil, backend)
+	assert.ErrorIs(t, err, bind.ErrNoChainID)
+
+	_, err = hdwallet.NewNonceManager(addr.Address(), nil)
+	assert.Error(t, err)
+}
+
+func TestHDWalletAddress_NonceManagerForChainID_ZeroValue(t *testing.T) {
+	var (
+		addr     hdwallet.HDWalletAddress
+		wg       sync.WaitGroup
+		managers = make([]*hdwallet.NonceManager, 8)
+	)
+
+	for i := range managers {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			managers[i], err = addr.NonceManagerForChainID(simulatedChainID, failingNonceBackend{})
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, manager := range managers {
+		assert.Same(t, managers[0], manager)
+	}
+
+	assert.Equal(t, common.Address{}, managers[0].Address())
+}
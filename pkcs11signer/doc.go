@@ -0,0 +1,11 @@
+// Package pkcs11signer keeps secp256k1 keys in a PKCS#11 token, such as an HSM or SoftHSM2,
+// and signs through it: keys derived by hdwallet can be imported into the token, or keys
+// can be generated inside it, and the returned Signer implements hdwallet.Signer with the
+// same address and transactor API as hdwallet.HDWalletAddress.
+//
+// Keys are stored as sensitive, non-extractable token objects identified by their
+// Ethereum address (CKA_ID holds the 20 address bytes).
+// The token must support CKM_ECDSA over secp256k1, which SoftHSM2 does.
+//
+// The package requires cgo, to load the token's PKCS#11 module.
+package pkcs11signer
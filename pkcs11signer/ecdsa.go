@@ -0,0 +1,71 @@
+package pkcs11signer
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// secp256k1OID is the DER-encoded object identifier of secp256k1 (1.3.132.0.10),
+// used as the keys' CKA_EC_PARAMS.
+var secp256k1OID = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+
+var secp256k1HalfN = new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+// encodeECPoint returns the DER OCTET STRING encoding of an uncompressed public key,
+// as stored in CKA_EC_POINT.
+func encodeECPoint(pubKey *ecdsa.PublicKey) []byte {
+	point := crypto.FromECDSAPub(pubKey)
+
+	return append([]byte{0x04, byte(len(point))}, point...)
+}
+
+// decodeECPoint parses a CKA_EC_POINT value, which tokens return either
+// DER-encoded (as PKCS#11 requires) or as the raw uncompressed point.
+func decodeECPoint(value []byte) (*ecdsa.PublicKey, error) {
+	point := value
+	if len(value) == 67 && value[0] == 0x04 && value[1] == 65 {
+		point = value[2:]
+	}
+
+	pubKey, err := crypto.UnmarshalPubkey(point)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid EC point")
+	}
+
+	return pubKey, nil
+}
+
+// recoverableSignature turns a raw CKM_ECDSA signature (r || s) of hash into a
+// 65-byte [R || S || V] signature like crypto.Sign, normalizing s to the lower half
+// of the curve order as required by Ethereum, and finding V by recovering pubKey.
+func recoverableSignature(hash, rs []byte, pubKey *ecdsa.PublicKey) ([]byte, error) {
+	if len(rs) != 64 {
+		return nil, errors.Errorf("invalid signature length %d", len(rs))
+	}
+
+	s := new(big.Int).SetBytes(rs[32:])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(crypto.S256().Params().N, s)
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, rs[:32])
+	s.FillBytes(sig[32:64])
+
+	want := crypto.FromECDSAPub(pubKey)
+
+	for v := byte(0); v < 2; v++ {
+		sig[crypto.RecoveryIDOffset] = v
+
+		recovered, err := crypto.Ecrecover(hash, sig)
+		if err == nil && bytes.Equal(recovered, want) {
+			return sig, nil
+		}
+	}
+
+	return nil, errors.New("signature doesn't match the key's public key")
+}
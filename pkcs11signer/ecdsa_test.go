@@ -0,0 +1,86 @@
+package pkcs11signer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECPoint(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	encoded := encodeECPoint(&key.PublicKey)
+	require.Len(t, encoded, 67)
+
+	tests := []struct {
+		name    string
+		value   []byte
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "DER",
+			value:   encoded,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "raw",
+			value:   encoded[2:],
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "truncated",
+			value:   encoded[:40],
+			wantErr: assert.Error,
+		},
+		{
+			name:    "compressed",
+			value:   crypto.CompressPubkey(&key.PublicKey),
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeECPoint(tt.value)
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			assert.Equal(t, key.PublicKey, *got)
+		})
+	}
+}
+
+func TestRecoverableSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	for i := 0; i < 16; i++ {
+		hash := crypto.Keccak256([]byte{byte(i)})
+
+		want, err := crypto.Sign(hash, key)
+		require.NoError(t, err)
+
+		// tokens return either half of the curve order for s
+		highS := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(want[32:64]))
+
+		for _, rs := range [][]byte{want[:64], append(append([]byte{}, want[:32]...), highS.FillBytes(make([]byte, 32))...)} {
+			got, err := recoverableSignature(hash, rs, &key.PublicKey)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			_, err = recoverableSignature(hash, rs, &other.PublicKey)
+			assert.Error(t, err)
+		}
+	}
+
+	_, err = recoverableSignature(make([]byte, 32), make([]byte, 63), &key.PublicKey)
+	assert.Error(t, err)
+}
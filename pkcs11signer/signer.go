@@ -0,0 +1,112 @@
+//go:build cgo
+
+package pkcs11signer
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+var _ hdwallet.Signer = (*Signer)(nil)
+
+// Signer signs using a private key held by a Token.
+type Signer struct {
+	token     *Token
+	handle    pkcs11.ObjectHandle
+	publicKey ecdsa.PublicKey
+	address   common.Address
+
+	mu          sync.Mutex
+	transactors map[string]*bind.TransactOpts
+}
+
+func (s *Signer) Address() common.Address {
+	return s.address
+}
+
+func (s *Signer) PublicKey() ecdsa.PublicKey {
+	return s.publicKey
+}
+
+func (s *Signer) PublicKeyHex() string {
+	return common.Bytes2Hex(s.PublicKeyBytes())
+}
+
+// PublicKeyBytes returns the uncompressed public key without its 0x04 prefix,
+// like HDWalletAddress.PublicKeyBytes.
+func (s *Signer) PublicKeyBytes() []byte {
+	return crypto.FromECDSAPub(&s.publicKey)[1:]
+}
+
+// SignHash signs hash inside the token (see hdwallet.Signer).
+func (s *Signer) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != common.HashLength {
+		return nil, errors.Errorf("hash is required to be exactly %d bytes (%d)", common.HashLength, len(hash))
+	}
+
+	rs, err := s.token.sign(s.handle, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return recoverableSignature(hash, rs, &s.publicKey)
+}
+
+// SignTx signs tx for chainID inside the token (see hdwallet.Signer).
+func (s *Signer) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+
+	txSigner := types.LatestSignerForChainID(chainID)
+
+	sig, err := s.SignHash(txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := tx.WithSignature(txSigner, sig)
+	if err != nil {
+		return nil, errors.Wrap(err, "error signing transaction")
+	}
+
+	return signed, nil
+}
+
+// TransactOptsForChainID returns a *bind.TransactOpts for the passed chainID which signs
+// transactions inside the token, like HDWalletAddress.TransactOptsForChainID.
+func (s *Signer) TransactOptsForChainID(chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existingTransactor, ok := s.transactors[chainID.String()]; ok {
+		return existingTransactor, nil
+	}
+
+	newTransactor, err := hdwallet.NewTransactOpts(s, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.transactors == nil {
+		s.transactors = make(map[string]*bind.TransactOpts)
+	}
+
+	s.transactors[chainID.String()] = newTransactor
+
+	return newTransactor, nil
+}
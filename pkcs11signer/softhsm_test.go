@@ -0,0 +1,228 @@
+//go:build cgo
+
+package pkcs11signer_test
+
+import (
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/pkcs11signer"
+)
+
+const (
+	testMnemonic   string = "test test test test test test test test test test test junk"
+	testTokenLabel string = "hdwallet"
+	testPIN        string = "1234"
+	testSOPIN      string = "5678"
+)
+
+// softHSMModules are the usual install locations of SoftHSM2's PKCS#11 module;
+// SOFTHSM2_MODULE takes precedence.
+var softHSMModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// newSoftHSM initializes a SoftHSM2 token in a temporary directory,
+// skipping the test if SoftHSM2 isn't installed, unless SOFTHSM2_REQUIRED is set (as in CI).
+func newSoftHSM(t *testing.T) string {
+	t.Helper()
+
+	module := os.Getenv("SOFTHSM2_MODULE")
+	if module == "" {
+		for _, path := range softHSMModules {
+			if _, err := os.Stat(path); err == nil {
+				module = path
+				break
+			}
+		}
+	}
+
+	util, err := exec.LookPath("softhsm2-util")
+	if module == "" || err != nil {
+		if os.Getenv("SOFTHSM2_REQUIRED") != "" {
+			t.Fatal("SoftHSM2 is not installed")
+		}
+
+		t.Skip("SoftHSM2 is not installed")
+	}
+
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	conf := filepath.Join(dir, "softhsm2.conf")
+
+	require.NoError(t, os.Mkdir(tokenDir, 0o700))
+	require.NoError(t, os.WriteFile(conf, []byte("directories.tokendir = "+tokenDir+"\nobjectstore.backend = file\n"), 0o600))
+
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	out, err := exec.Command(util, "--init-token", "--free", "--label", testTokenLabel, "--pin", testPIN, "--so-pin", testSOPIN).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return module
+}
+
+func openToken(t *testing.T, module string) *pkcs11signer.Token {
+	t.Helper()
+
+	token, err := pkcs11signer.Open(module, testTokenLabel, testPIN)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = token.Close() })
+
+	return token
+}
+
+func assertSigns(t *testing.T, signer hdwallet.Signer) {
+	t.Helper()
+
+	hash := crypto.Keccak256([]byte("hello hsm"))
+
+	sig, err := signer.SignHash(hash)
+	require.NoError(t, err)
+
+	pubKey, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	assert.Equal(t, signer.Address(), crypto.PubkeyToAddress(*pubKey))
+
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID: chainID, Nonce: 3, To: &to, Value: big.NewInt(1),
+		Gas: 21000, GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9),
+	})
+
+	opts, err := hdwallet.NewTransactOpts(signer, chainID)
+	require.NoError(t, err)
+
+	signed, err := opts.Signer(opts.From, tx)
+	require.NoError(t, err)
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	require.NoError(t, err)
+	assert.Equal(t, signer.Address(), sender)
+}
+
+func TestSoftHSM(t *testing.T) {
+	module := newSoftHSM(t)
+
+	_, err := pkcs11signer.Open(module, testTokenLabel, "0000")
+	assert.Error(t, err, "wrong pin")
+
+	_, err = pkcs11signer.Open(module, "missing", testPIN)
+	assert.Error(t, err, "unknown token")
+
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	addrs, err := w.DeriveRange(0, 2)
+	require.NoError(t, err)
+
+	token := openToken(t, module)
+
+	var imported []*pkcs11signer.Signer
+
+	t.Run("ImportKey", func(t *testing.T) {
+		for _, addr := range addrs {
+			signer, err := token.ImportKey(addr)
+			require.NoError(t, err)
+
+			assert.Equal(t, addr.Address(), signer.Address())
+			assert.Equal(t, addr.PublicKeyHex(), signer.PublicKeyHex())
+
+			assertSigns(t, signer)
+
+			opts, err := signer.TransactOptsForChainID(big.NewInt(1))
+			require.NoError(t, err)
+			assert.Equal(t, addr.Address(), opts.From)
+
+			imported = append(imported, signer)
+		}
+
+		again, err := token.ImportKey(addrs[0])
+		require.NoError(t, err)
+		assert.Equal(t, addrs[0].Address(), again.Address())
+	})
+
+	var generated *pkcs11signer.Signer
+
+	t.Run("GenerateKey", func(t *testing.T) {
+		generated, err = token.GenerateKey()
+		require.NoError(t, err)
+
+		assertSigns(t, generated)
+	})
+
+	require.NoError(t, token.Close())
+
+	_, err = imported[0].SignHash(crypto.Keccak256(nil))
+	assert.Error(t, err, "token is closed")
+
+	t.Run("FindKey after reopening", func(t *testing.T) {
+		token := openToken(t, module)
+
+		for _, addr := range append(addrs, nil) {
+			address := generated.Address()
+			if addr != nil {
+				address = addr.Address()
+			}
+
+			signer, err := token.FindKey(address)
+			require.NoError(t, err)
+
+			assertSigns(t, signer)
+		}
+
+		_, err := token.FindKey(common.HexToAddress("0x01"))
+		assert.Error(t, err)
+
+		signers, err := token.Signers()
+		require.NoError(t, err)
+		assert.Len(t, signers, len(addrs)+1)
+	})
+}
+
+func TestSoftHSM_SharedModule(t *testing.T) {
+	module := newSoftHSM(t)
+
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	addr, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	first := openToken(t, module)
+	second := openToken(t, module)
+
+	_, err = first.ImportKey(addr)
+	require.NoError(t, err)
+
+	signer, err := second.FindKey(addr.Address())
+	require.NoError(t, err)
+
+	// closing a token mustn't finalize the module, or log out, while another token uses it.
+	require.NoError(t, first.Close())
+
+	assertSigns(t, signer)
+
+	third := openToken(t, module)
+
+	signer, err = third.FindKey(addr.Address())
+	require.NoError(t, err)
+
+	assertSigns(t, signer)
+}
@@ -0,0 +1,476 @@
+//go:build cgo
+
+package pkcs11signer
+
+import (
+	"crypto/ecdsa"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+// Token is a logged-in session with a PKCS#11 token.
+// It is safe for concurrent use; operations on the token are serialized.
+type Token struct {
+	mu         sync.Mutex
+	ctx        *pkcs11.Ctx
+	modulePath string
+	session    pkcs11.SessionHandle
+	closed     bool
+}
+
+// loadedModule is a PKCS#11 module shared by every open Token using it.
+type loadedModule struct {
+	ctx  *pkcs11.Ctx
+	refs int
+	// owned is false if the module was already initialized by other code in the process,
+	// in which case it's left for that code to finalize.
+	owned bool
+}
+
+// loadedModules holds the modules loaded by Open, by path.
+// C_Finalize ends every session the process has with a module, so a module is
+// only finalized once the last Token using it is closed.
+var loadedModules = struct {
+	sync.Mutex
+	m map[string]*loadedModule
+}{m: make(map[string]*loadedModule)}
+
+// Open loads the PKCS#11 module at modulePath, such as /usr/lib/softhsm/libsofthsm2.so,
+// and logs into the token labeled tokenLabel using the user pin.
+// Tokens opened from the same module share it, and closing one doesn't affect the others.
+func Open(modulePath, tokenLabel, pin string) (*Token, error) {
+	ctx, err := acquireModule(modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Token{ctx: ctx, modulePath: modulePath}
+
+	if err := t.login(tokenLabel, pin); err != nil {
+		_ = releaseModule(modulePath)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// acquireModule returns the module at modulePath, loading and initializing it
+// unless it's already in use.
+func acquireModule(modulePath string) (*pkcs11.Ctx, error) {
+	loadedModules.Lock()
+	defer loadedModules.Unlock()
+
+	if mod, ok := loadedModules.m[modulePath]; ok {
+		mod.refs++
+		return mod.ctx, nil
+	}
+
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		return nil, errors.Errorf("error loading PKCS#11 module %s", modulePath)
+	}
+
+	owned := true
+
+	if err := ctx.Initialize(); err != nil {
+		if !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+			ctx.Destroy()
+			return nil, errors.Wrap(err, "error initializing PKCS#11 module")
+		}
+
+		owned = false
+	}
+
+	loadedModules.m[modulePath] = &loadedModule{ctx: ctx, refs: 1, owned: owned}
+
+	return ctx, nil
+}
+
+// releaseModule releases a reference to the module at modulePath,
+// finalizing and unloading it once it's no longer in use.
+func releaseModule(modulePath string) error {
+	loadedModules.Lock()
+	defer loadedModules.Unlock()
+
+	mod, ok := loadedModules.m[modulePath]
+	if !ok {
+		return nil
+	}
+
+	if mod.refs--; mod.refs > 0 {
+		return nil
+	}
+
+	delete(loadedModules.m, modulePath)
+
+	var err error
+	if mod.owned {
+		err = mod.ctx.Finalize()
+	}
+
+	mod.ctx.Destroy()
+
+	return err
+}
+
+func (t *Token) login(tokenLabel, pin string) error {
+	slots, err := t.ctx.GetSlotList(true)
+	if err != nil {
+		return errors.Wrap(err, "error listing slots")
+	}
+
+	for _, slot := range slots {
+		info, err := t.ctx.GetTokenInfo(slot)
+		if err != nil || info.Label != tokenLabel {
+			continue
+		}
+
+		t.session, err = t.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err != nil {
+			return errors.Wrapf(err, "error opening session with token %q", tokenLabel)
+		}
+
+		err = t.ctx.Login(t.session, pkcs11.CKU_USER, pin)
+		if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			_ = t.ctx.CloseSession(t.session)
+			return errors.Wrapf(err, "error logging into token %q", tokenLabel)
+		}
+
+		return nil
+	}
+
+	return errors.Errorf("token %q not found", tokenLabel)
+}
+
+// Close logs out of the token, and unloads the module unless other tokens still use it.
+// Signers obtained from the token can't be used afterwards.
+func (t *Token) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil
+	}
+
+	t.closed = true
+
+	// logging out would log every other session with the token out as well;
+	// the token logs out by itself when its last session is closed.
+	err := t.ctx.CloseSession(t.session)
+
+	if releaseErr := releaseModule(t.modulePath); err == nil {
+		err = releaseErr
+	}
+
+	return errors.Wrap(err, "error closing token")
+}
+
+// ImportKey stores addr's private key in the token, returning a Signer for it.
+// If the token already holds a key for the address, it is returned instead.
+// The address' wallet must be unlocked.
+func (t *Token) ImportKey(addr *hdwallet.HDWalletAddress) (*Signer, error) {
+	if addr == nil {
+		return nil, errors.New("address must not be nil")
+	}
+
+	privKey, err := addr.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	defer wipeKey(privKey)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	address := addr.Address()
+
+	if signer, err := t.findKey(address); err == nil {
+		return signer, nil
+	}
+
+	id, label := address.Bytes(), address.Hex()
+
+	privBytes := crypto.FromECDSA(privKey)
+	defer wipeBytes(privBytes)
+
+	privHandle, err := t.ctx.CreateObject(t.session, append(privateKeyTemplate(id, label),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, privBytes),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "error importing private key")
+	}
+
+	_, err = t.ctx.CreateObject(t.session, append(publicKeyTemplate(id, label),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, encodeECPoint(&privKey.PublicKey)),
+	))
+	if err != nil {
+		_ = t.ctx.DestroyObject(t.session, privHandle)
+		return nil, errors.Wrap(err, "error importing public key")
+	}
+
+	return t.newSigner(privHandle, privKey.PublicKey), nil
+}
+
+// GenerateKey generates a key pair inside the token, returning a Signer for it.
+// The private key never leaves the token, so it can't be backed up using a mnemonic.
+func (t *Token) GenerateKey() (*Signer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	pubHandle, privHandle, err := t.ctx.GenerateKeyPair(
+		t.session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		append(publicKeyTemplate(nil, ""), pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID)),
+		privateKeyTemplate(nil, ""),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating key pair")
+	}
+
+	pubKey, err := t.publicKey(pubHandle)
+	if err != nil {
+		_ = t.ctx.DestroyObject(t.session, pubHandle)
+		_ = t.ctx.DestroyObject(t.session, privHandle)
+
+		return nil, err
+	}
+
+	// the address is only known once the key exists, so identify the key pair afterwards
+	address := crypto.PubkeyToAddress(*pubKey)
+	attrs := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_ID, address.Bytes()),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, address.Hex()),
+	}
+
+	for _, handle := range []pkcs11.ObjectHandle{pubHandle, privHandle} {
+		if err := t.ctx.SetAttributeValue(t.session, handle, attrs); err != nil {
+			_ = t.ctx.DestroyObject(t.session, pubHandle)
+			_ = t.ctx.DestroyObject(t.session, privHandle)
+
+			return nil, errors.Wrap(err, "error labeling generated key pair")
+		}
+	}
+
+	return t.newSigner(privHandle, *pubKey), nil
+}
+
+// FindKey returns a Signer for the token's key for address.
+func (t *Token) FindKey(address common.Address) (*Signer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	return t.findKey(address)
+}
+
+// Signers returns a Signer for every key stored in the token by this package.
+func (t *Token) Signers() ([]*Signer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	handles, err := t.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var signers []*Signer
+
+	for _, handle := range handles {
+		attrs, err := t.ctx.GetAttributeValue(t.session, handle, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, nil)})
+		if err != nil || len(attrs) != 1 || len(attrs[0].Value) != common.AddressLength {
+			continue
+		}
+
+		signer, err := t.findKey(common.BytesToAddress(attrs[0].Value))
+		if err != nil {
+			continue
+		}
+
+		signers = append(signers, signer)
+	}
+
+	return signers, nil
+}
+
+func (t *Token) findKey(address common.Address) (*Signer, error) {
+	privHandles, err := t.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, address.Bytes()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pubHandles, err := t.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, address.Bytes()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(privHandles) == 0 || len(pubHandles) == 0 {
+		return nil, errors.Errorf("no key found for address %s", address)
+	}
+
+	pubKey, err := t.publicKey(pubHandles[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if crypto.PubkeyToAddress(*pubKey) != address {
+		return nil, errors.Errorf("public key stored for %s doesn't match its address", address)
+	}
+
+	return t.newSigner(privHandles[0], *pubKey), nil
+}
+
+func (t *Token) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := t.ctx.FindObjectsInit(t.session, template); err != nil {
+		return nil, errors.Wrap(err, "error searching token")
+	}
+
+	defer func() { _ = t.ctx.FindObjectsFinal(t.session) }()
+
+	var handles []pkcs11.ObjectHandle
+
+	for {
+		found, _, err := t.ctx.FindObjects(t.session, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "error searching token")
+		}
+
+		if len(found) == 0 {
+			return handles, nil
+		}
+
+		handles = append(handles, found...)
+	}
+}
+
+func (t *Token) publicKey(handle pkcs11.ObjectHandle) (*ecdsa.PublicKey, error) {
+	attrs, err := t.ctx.GetAttributeValue(t.session, handle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading public key")
+	}
+
+	if len(attrs) != 1 {
+		return nil, errors.New("error reading public key: no EC point")
+	}
+
+	return decodeECPoint(attrs[0].Value)
+}
+
+// sign signs hash with the private key at handle using CKM_ECDSA.
+func (t *Token) sign(handle pkcs11.ObjectHandle, hash []byte) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if err := t.ctx.SignInit(t.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, handle); err != nil {
+		return nil, errors.Wrap(err, "error initializing signature")
+	}
+
+	rs, err := t.ctx.Sign(t.session, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "error signing")
+	}
+
+	return rs, nil
+}
+
+func (t *Token) checkOpen() error {
+	if t.closed {
+		return errors.New("token is closed")
+	}
+
+	return nil
+}
+
+func (t *Token) newSigner(handle pkcs11.ObjectHandle, pubKey ecdsa.PublicKey) *Signer {
+	return &Signer{
+		token:     t,
+		handle:    handle,
+		publicKey: pubKey,
+		address:   crypto.PubkeyToAddress(pubKey),
+	}
+}
+
+func privateKeyTemplate(id []byte, label string) []*pkcs11.Attribute {
+	return append(keyIdentity(id, label),
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+	)
+}
+
+func publicKeyTemplate(id []byte, label string) []*pkcs11.Attribute {
+	return append(keyIdentity(id, label),
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+	)
+}
+
+func keyIdentity(id []byte, label string) []*pkcs11.Attribute {
+	if id == nil {
+		return nil
+	}
+
+	return []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+}
+
+// wipeKey zeroes key's private scalar in place.
+func wipeKey(key *ecdsa.PrivateKey) {
+	words := key.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+
+	key.D.SetInt64(0)
+}
+
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,258 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a declarative signing policy, usually loaded from YAML or JSON
+// using ParseConfig or LoadConfig:
+//
+//	recipients:
+//	  deny: ["0x000000000000000000000000000000000000dEaD"]
+//	selectors:
+//	  allow: ["0xa9059cbb", "0x095ea7b3"]
+//	limits:
+//	  - name: hot wallet on mainnet
+//	    address: "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+//	    chain_id: 1
+//	    max_value: 1 ether
+//	    daily_value: 5 ether
+//	    rate_limit: {requests: 10, per: 1m}
+//	approval:
+//	  value_above: 0.5 ether
+//	  contract_creation: true
+type Config struct {
+	// Recipients restricts the addresses transactions can be sent to.
+	Recipients AddressList `yaml:"recipients" json:"recipients"`
+	// Selectors restricts the contract functions transactions can call,
+	// identified by the first 4 bytes of their calldata.
+	// Transactions without calldata are not subject to Selectors, while transactions
+	// whose calldata is shorter than a selector are denied if Allow isn't empty.
+	Selectors SelectorList `yaml:"selectors" json:"selectors"`
+	// Limits are value and rate limits, each applying to the requests matching its scope.
+	Limits []Limit `yaml:"limits" json:"limits"`
+	// Approval lists the requests requiring approval by the Engine's ApprovalFunc.
+	Approval Approval `yaml:"approval" json:"approval"`
+}
+
+// AddressList is an allow list and a deny list of addresses.
+// If Allow is not empty, only the addresses it contains are allowed;
+// addresses in Deny are always denied.
+type AddressList struct {
+	Allow []common.Address `yaml:"allow" json:"allow"`
+	Deny  []common.Address `yaml:"deny" json:"deny"`
+}
+
+// SelectorList is an allow list and a deny list of function selectors,
+// with the same semantics as AddressList.
+type SelectorList struct {
+	Allow []Selector `yaml:"allow" json:"allow"`
+	Deny  []Selector `yaml:"deny" json:"deny"`
+}
+
+// Limit limits the value and rate of the requests in its scope.
+// A Limit with neither Address nor ChainID applies to every request,
+// and its totals are shared by every address and chain.
+type Limit struct {
+	// Name identifies the limit in decisions; defaults to "limits[<index>]".
+	Name string `yaml:"name" json:"name"`
+	// Address restricts the limit to requests signed by an address.
+	Address *common.Address `yaml:"address" json:"address"`
+	// ChainID restricts the limit to transactions on a chain.
+	// Limits with a ChainID don't apply to messages.
+	ChainID *uint64 `yaml:"chain_id" json:"chain_id"`
+	// MaxValue is the maximum value of a single transaction.
+	MaxValue *Wei `yaml:"max_value" json:"max_value"`
+	// DailyValue is the maximum total value of the transactions signed in the past 24 hours.
+	DailyValue *Wei `yaml:"daily_value" json:"daily_value"`
+	// RateLimit is the maximum number of requests signed in a period of time.
+	RateLimit *RateLimit `yaml:"rate_limit" json:"rate_limit"`
+}
+
+// RateLimit allows up to Requests requests in any period of duration Per.
+type RateLimit struct {
+	Requests int      `yaml:"requests" json:"requests"`
+	Per      Duration `yaml:"per" json:"per"`
+}
+
+// Approval lists the requests which must be approved before being signed.
+type Approval struct {
+	// ValueAbove requires approval of transactions with a value strictly greater than it.
+	ValueAbove *Wei `yaml:"value_above" json:"value_above"`
+	// ContractCreation requires approval of contract creation transactions.
+	ContractCreation bool `yaml:"contract_creation" json:"contract_creation"`
+	// Messages requires approval of every message signature.
+	Messages bool `yaml:"messages" json:"messages"`
+}
+
+// ParseConfig parses a YAML or JSON policy, rejecting unknown fields.
+func ParseConfig(data []byte) (*Config, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	cfg := new(Config)
+	if err := dec.Decode(cfg); err != nil {
+		return nil, errors.Wrap(err, "error parsing policy")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadConfig reads and parses the YAML or JSON policy file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading policy")
+	}
+
+	return ParseConfig(data)
+}
+
+// Validate checks that the policy's limits are well-formed.
+func (c *Config) Validate() error {
+	for i, limit := range c.Limits {
+		name := limitName(limit, i)
+
+		if limit.MaxValue != nil && limit.MaxValue.Int().Sign() < 0 {
+			return errors.Errorf("%s: max_value must not be negative", name)
+		}
+
+		if limit.DailyValue != nil && limit.DailyValue.Int().Sign() < 0 {
+			return errors.Errorf("%s: daily_value must not be negative", name)
+		}
+
+		if rl := limit.RateLimit; rl != nil && (rl.Requests <= 0 || rl.Per <= 0) {
+			return errors.Errorf("%s: rate_limit requires positive requests and per", name)
+		}
+	}
+
+	if v := c.Approval.ValueAbove; v != nil && v.Int().Sign() < 0 {
+		return errors.New("approval: value_above must not be negative")
+	}
+
+	return nil
+}
+
+func limitName(limit Limit, idx int) string {
+	if limit.Name != "" {
+		return limit.Name
+	}
+
+	return "limits[" + strconv.Itoa(idx) + "]"
+}
+
+// Selector is a 4-byte function selector, encoded as hex.
+type Selector [4]byte
+
+func (s Selector) String() string {
+	return "0x" + hex.EncodeToString(s[:])
+}
+
+func (s Selector) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Selector) UnmarshalText(text []byte) error {
+	raw := strings.TrimPrefix(strings.TrimPrefix(string(text), "0x"), "0X")
+
+	decoded, err := hex.DecodeString(raw)
+	if err != nil || len(decoded) != len(s) {
+		return errors.Errorf("invalid function selector %q", text)
+	}
+
+	copy(s[:], decoded)
+
+	return nil
+}
+
+// Wei is an amount of ether, written as an integer number of wei
+// or as a decimal number followed by a unit: wei, gwei or ether.
+type Wei big.Int
+
+var weiUnits = map[string]*big.Int{
+	"wei":   big.NewInt(1),
+	"gwei":  big.NewInt(params.GWei),
+	"ether": big.NewInt(params.Ether),
+}
+
+// NewWei returns v as a *Wei.
+func NewWei(v *big.Int) *Wei {
+	return (*Wei)(new(big.Int).Set(v))
+}
+
+// Int returns the amount in wei.
+func (w *Wei) Int() *big.Int {
+	return (*big.Int)(w)
+}
+
+func (w *Wei) String() string {
+	return w.Int().String()
+}
+
+func (w *Wei) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+func (w *Wei) UnmarshalText(text []byte) error {
+	amount, unit := strings.TrimSpace(string(text)), "wei"
+
+	if i := strings.LastIndexAny(amount, "0123456789"); i >= 0 && i < len(amount)-1 {
+		amount, unit = strings.TrimSpace(amount[:i+1]), strings.ToLower(strings.TrimSpace(amount[i+1:]))
+	}
+
+	multiplier, ok := weiUnits[unit]
+	if !ok {
+		return errors.Errorf("invalid amount %q: unknown unit %q", text, unit)
+	}
+
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return errors.Errorf("invalid amount %q", text)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(multiplier))
+	if !r.IsInt() {
+		return errors.Errorf("invalid amount %q: fractional wei", text)
+	}
+
+	w.Int().Set(r.Num())
+
+	return nil
+}
+
+// Duration is a time.Duration encoded as a string, such as "1m" or "24h".
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration %q", text)
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package policy_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go/policy"
+)
+
+const testPolicyYAML string = `
+recipients:
+  deny: [0x000000000000000000000000000000000000dEaD]
+selectors:
+  allow: ["0xa9059cbb"]
+limits:
+  - name: hot wallet
+    address: 0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266
+    chain_id: 1
+    max_value: 1 ether
+    daily_value: 5000000000000000000
+    rate_limit: {requests: 10, per: 1m}
+approval:
+  value_above: 0.5 ether
+  messages: true
+`
+
+const testPolicyJSON string = `{
+  "recipients": {"deny": ["0x000000000000000000000000000000000000dEaD"]},
+  "selectors": {"allow": ["0xa9059cbb"]},
+  "limits": [{
+    "name": "hot wallet",
+    "address": "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+    "chain_id": 1,
+    "max_value": "1 ether",
+    "daily_value": "5000000000000000000",
+    "rate_limit": {"requests": 10, "per": "1m"}
+  }],
+  "approval": {"value_above": "0.5 ether", "messages": true}
+}`
+
+func TestParseConfig(t *testing.T) {
+	chainID := uint64(1)
+	hotWallet := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+
+	want := &policy.Config{
+		Recipients: policy.AddressList{
+			Deny: []common.Address{common.HexToAddress("0x000000000000000000000000000000000000dEaD")},
+		},
+		Selectors: policy.SelectorList{
+			Allow: []policy.Selector{{0xa9, 0x05, 0x9c, 0xbb}},
+		},
+		Limits: []policy.Limit{{
+			Name:       "hot wallet",
+			Address:    &hotWallet,
+			ChainID:    &chainID,
+			MaxValue:   policy.NewWei(big.NewInt(1e18)),
+			DailyValue: policy.NewWei(big.NewInt(5e18)),
+			RateLimit:  &policy.RateLimit{Requests: 10, Per: policy.Duration(time.Minute)},
+		}},
+		Approval: policy.Approval{
+			ValueAbove: policy.NewWei(big.NewInt(5e17)),
+			Messages:   true,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    *policy.Config
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "yaml",
+			data:    testPolicyYAML,
+			want:    want,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "json",
+			data:    testPolicyJSON,
+			want:    want,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "empty",
+			data:    "{}",
+			want:    &policy.Config{},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "unknown field",
+			data:    "recipients: {block: []}",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid address",
+			data:    "recipients: {deny: [0x1234]}",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid selector",
+			data:    `selectors: {deny: ["0xa9059c"]}`,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid amount unit",
+			data:    "limits: [{max_value: 1 btc}]",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "fractional wei",
+			data:    "limits: [{max_value: 0.5 wei}]",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "negative amount",
+			data:    "limits: [{daily_value: -1 gwei}]",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid rate limit",
+			data:    "limits: [{rate_limit: {requests: 0, per: 1m}}]",
+			wantErr: assert.Error,
+		},
+		{
+			name:    "invalid duration",
+			data:    "limits: [{rate_limit: {requests: 1, per: soon}}]",
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.ParseConfig([]byte(tt.data))
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicyYAML), 0o600))
+
+	cfg, err := policy.LoadConfig(path)
+	require.NoError(t, err)
+
+	assert.Len(t, cfg.Limits, 1)
+
+	_, err = policy.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestWei_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    *big.Int
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{text: "42", want: big.NewInt(42), wantErr: assert.NoError},
+		{text: "42 wei", want: big.NewInt(42), wantErr: assert.NoError},
+		{text: "20gwei", want: big.NewInt(20e9), wantErr: assert.NoError},
+		{text: "1.5 ether", want: big.NewInt(15e17), wantErr: assert.NoError},
+		{text: "2 ETHER", want: big.NewInt(2e18), wantErr: assert.NoError},
+		{text: "0.1 gwei", want: big.NewInt(1e8), wantErr: assert.NoError},
+		{text: "ether", wantErr: assert.Error},
+		{text: "1 finney", wantErr: assert.Error},
+		{text: "1.5", wantErr: assert.Error},
+		{text: "", wantErr: assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var w policy.Wei
+
+			err := w.UnmarshalText([]byte(tt.text))
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.want, w.Int())
+
+			data, err := json.Marshal(&w)
+			require.NoError(t, err)
+			assert.Equal(t, `"`+tt.want.String()+`"`, string(data))
+		})
+	}
+}
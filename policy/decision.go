@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Kinds of requests.
+const (
+	KindTransaction string = "transaction"
+	KindMessage     string = "message"
+)
+
+// Decision is the outcome of evaluating a Request.
+type Decision struct {
+	Time time.Time `json:"time"`
+	// Kind is either KindTransaction or KindMessage.
+	Kind    string         `json:"kind"`
+	From    common.Address `json:"from"`
+	ChainID *big.Int       `json:"chain_id,omitempty"`
+	// To is the recipient of the transaction, or nil for contract creations and messages.
+	To *common.Address `json:"to,omitempty"`
+	// Value is the transaction's value in wei, or nil for messages.
+	Value *big.Int `json:"value,omitempty"`
+	// Selector is the function selector of the transaction's calldata, if any.
+	Selector string `json:"selector,omitempty"`
+	// MessageHash is the keccak256 hash of the raw message, for messages.
+	MessageHash hexutil.Bytes `json:"message_hash,omitempty"`
+
+	Allowed bool `json:"allowed"`
+	// Rule is the rule which denied the request, such as "recipients.deny"
+	// or "<limit name>.daily_value", or empty if it was allowed.
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason"`
+	// ApprovalRequired is set if the request had to be approved,
+	// in which case Approved reports whether it was.
+	ApprovalRequired bool `json:"approval_required,omitempty"`
+	Approved         bool `json:"approved,omitempty"`
+}
+
+func newDecision(now time.Time, req *Request) *Decision {
+	decision := &Decision{
+		Time: now,
+		Kind: req.kind(),
+		From: req.From,
+	}
+
+	if req.Tx == nil {
+		decision.MessageHash = crypto.Keccak256(req.Message)
+		return decision
+	}
+
+	if req.ChainID != nil {
+		decision.ChainID = new(big.Int).Set(req.ChainID)
+	}
+
+	decision.To = req.Tx.To()
+	decision.Value = req.value()
+
+	if selector, ok := txSelector(req.Tx); ok {
+		decision.Selector = selector.String()
+	}
+
+	return decision
+}
+
+// DecisionLogger records decisions.
+type DecisionLogger interface {
+	LogDecision(decision *Decision)
+}
+
+// DecisionLoggerFunc adapts a function to a DecisionLogger.
+type DecisionLoggerFunc func(decision *Decision)
+
+func (f DecisionLoggerFunc) LogDecision(decision *Decision) {
+	f(decision)
+}
+
+// JSONDecisionLogger writes every decision to an io.Writer as a line of JSON.
+type JSONDecisionLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONDecisionLogger returns a JSONDecisionLogger writing to w.
+func NewJSONDecisionLogger(w io.Writer) *JSONDecisionLogger {
+	return &JSONDecisionLogger{enc: json.NewEncoder(w)}
+}
+
+func (l *JSONDecisionLogger) LogDecision(decision *Decision) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_ = l.enc.Encode(decision)
+}
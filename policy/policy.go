@@ -0,0 +1,487 @@
+// Package policy evaluates signing requests against a declarative policy before
+// they are signed: allow and deny lists of recipients and function selectors,
+// per-address and per-chain value limits, rolling daily spend windows, rate limits
+// and mandatory approval of requests above thresholds.
+//
+// Every evaluation produces a Decision, which is recorded by the Engine's DecisionLogger.
+// Allowed requests only count towards daily windows and rate limits once they're
+// signed, which callers of Engine.Check report using Engine.Commit or Engine.Release.
+// Engine.TransactOpts and Engine.SignTx wrap an hdwallet.Signer,
+// and the clef and web3signer servers accept an Engine using their WithPolicy options.
+package policy
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// dailyWindow is the period summed by Limit.DailyValue.
+const dailyWindow = 24 * time.Hour
+
+// ErrDenied is matched by the errors returned for denied requests, using errors.Is.
+var ErrDenied = errors.New("denied by signing policy")
+
+// DeniedError is returned for requests denied by a policy.
+type DeniedError struct {
+	Decision *Decision
+}
+
+func (e *DeniedError) Error() string {
+	return ErrDenied.Error() + ": " + e.Decision.Reason
+}
+
+func (e *DeniedError) Is(target error) bool {
+	return target == ErrDenied
+}
+
+// Request is a request to sign either a transaction or a message.
+type Request struct {
+	// From is the address asked to sign.
+	From common.Address
+	// ChainID is the chain ID the transaction is signed for; it is ignored for messages.
+	ChainID *big.Int
+	// Tx is the unsigned transaction, or nil when signing a message.
+	Tx *types.Transaction
+	// Message is the raw message being signed, before hashing.
+	Message []byte
+}
+
+func (r *Request) kind() string {
+	if r.Tx != nil {
+		return KindTransaction
+	}
+
+	return KindMessage
+}
+
+func (r *Request) value() *big.Int {
+	if r.Tx == nil || r.Tx.Value() == nil {
+		return new(big.Int)
+	}
+
+	return r.Tx.Value()
+}
+
+// ApprovalFunc decides whether a request requiring approval is signed,
+// for example by prompting an operator. reason describes why approval is required.
+// Requests are only signed if it returns true and a nil error.
+type ApprovalFunc func(ctx context.Context, req *Request, reason string) (bool, error)
+
+type engineOpts struct {
+	approve ApprovalFunc
+	logger  DecisionLogger
+	now     func() time.Time
+}
+
+type funcEngineOpt struct {
+	f func(*engineOpts)
+}
+
+func newFuncEngineOpt(f func(*engineOpts)) *funcEngineOpt {
+	return &funcEngineOpt{f}
+}
+
+func (fo *funcEngineOpt) apply(opts *engineOpts) {
+	fo.f(opts)
+}
+
+type EngineOpt interface {
+	apply(*engineOpts)
+}
+
+// WithApprovalFunc sets the function asked to approve requests
+// matching the policy's Approval section.
+// Without one, requests requiring approval are denied.
+func WithApprovalFunc(approve ApprovalFunc) EngineOpt {
+	return newFuncEngineOpt(func(opts *engineOpts) {
+		opts.approve = approve
+	})
+}
+
+// WithDecisionLogger sets the logger recording every decision.
+func WithDecisionLogger(logger DecisionLogger) EngineOpt {
+	return newFuncEngineOpt(func(opts *engineOpts) {
+		opts.logger = logger
+	})
+}
+
+// WithClock sets the function returning the current time,
+// used for daily windows and rate limits. Defaults to time.Now.
+func WithClock(now func() time.Time) EngineOpt {
+	return newFuncEngineOpt(func(opts *engineOpts) {
+		opts.now = now
+	})
+}
+
+func defaultEngineOpts() *engineOpts {
+	return &engineOpts{
+		now: time.Now,
+	}
+}
+
+// event is a request allowed within a limit's scope.
+type event struct {
+	time  time.Time
+	value *big.Int
+	// pending is the allowed request until it's committed, or nil once it's signed.
+	pending *Request
+}
+
+// Engine evaluates requests against a Config, keeping track of the requests
+// it allowed for daily windows and rate limits.
+// Requests are evaluated one at a time, including while awaiting approval,
+// and allowed requests count towards limits until they're released,
+// so that concurrent requests can't exceed limits.
+type Engine struct {
+	cfg  *Config
+	opts *engineOpts
+
+	mu      sync.Mutex
+	history [][]event // signed requests, by limit index
+}
+
+// NewEngine returns an Engine evaluating requests against cfg.
+func NewEngine(cfg *Config, opts ...EngineOpt) (*Engine, error) {
+	if cfg == nil {
+		return nil, errors.New("policy config must not be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	eOpts := defaultEngineOpts()
+	for _, o := range opts {
+		o.apply(eOpts)
+	}
+
+	return &Engine{
+		cfg:     cfg,
+		opts:    eOpts,
+		history: make([][]event, len(cfg.Limits)),
+	}, nil
+}
+
+// Check evaluates req, returning the logged decision.
+// If the request is denied, the returned error is a *DeniedError.
+//
+// An allowed request is pending: it counts towards the policy's daily windows
+// and rate limits until it's passed to Release, which callers must do if it isn't
+// signed after all, for example because an operator refused it or signing failed.
+// Once it's signed, callers must pass it to Commit.
+func (e *Engine) Check(ctx context.Context, req *Request) (*Decision, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.opts.now()
+	decision := newDecision(now, req)
+
+	rule, reason, err := e.evaluate(ctx, req, now, decision)
+	decision.Rule = rule
+	decision.Reason = reason
+
+	switch {
+	case err != nil:
+		decision.Reason = err.Error()
+	case rule == "":
+		decision.Allowed = true
+		if decision.Reason == "" {
+			decision.Reason = "allowed"
+		}
+
+		e.record(req, now)
+	}
+
+	if e.opts.logger != nil {
+		e.opts.logger.LogDecision(decision)
+	}
+
+	if err != nil {
+		return decision, errors.Wrap(err, "error evaluating signing policy")
+	}
+
+	if !decision.Allowed {
+		return decision, &DeniedError{Decision: decision}
+	}
+
+	return decision, nil
+}
+
+// evaluate returns the rule denying req along with the reason,
+// or an empty rule if req is allowed, with the reason if it was approved.
+func (e *Engine) evaluate(ctx context.Context, req *Request, now time.Time, decision *Decision) (rule, reason string, err error) {
+	if req.Tx != nil {
+		if rule, reason = e.checkLists(req.Tx); rule != "" {
+			return rule, reason, nil
+		}
+	}
+
+	if rule, reason = e.checkLimits(req, now); rule != "" {
+		return rule, reason, nil
+	}
+
+	approvalReason := e.approvalReason(req)
+	if approvalReason == "" {
+		return "", "", nil
+	}
+
+	decision.ApprovalRequired = true
+
+	if e.opts.approve == nil {
+		return "approval", approvalReason + ", and no approval function is configured", nil
+	}
+
+	approved, err := e.opts.approve(ctx, req, approvalReason)
+	if err != nil {
+		return "approval", "", errors.Wrap(err, "error requesting approval")
+	}
+
+	decision.Approved = approved
+	if !approved {
+		return "approval", approvalReason + ", and the request was rejected", nil
+	}
+
+	return "", approvalReason + ", and the request was approved", nil
+}
+
+func (e *Engine) checkLists(tx *types.Transaction) (rule, reason string) {
+	recipients := e.cfg.Recipients
+
+	if to := tx.To(); to != nil {
+		if containsAddress(recipients.Deny, *to) {
+			return "recipients.deny", "recipient " + to.Hex() + " is denied"
+		}
+
+		if len(recipients.Allow) > 0 && !containsAddress(recipients.Allow, *to) {
+			return "recipients.allow", "recipient " + to.Hex() + " is not allowed"
+		}
+	} else if len(recipients.Allow) > 0 {
+		return "recipients.allow", "contract creation is not allowed by the recipient allow list"
+	}
+
+	selector, ok := txSelector(tx)
+	if !ok {
+		// calldata too short to hold a selector reaches the contract's fallback function.
+		if tx.To() != nil && len(tx.Data()) > 0 && len(e.cfg.Selectors.Allow) > 0 {
+			return "selectors.allow", "calldata shorter than a function selector is not allowed"
+		}
+
+		return "", ""
+	}
+
+	if containsSelector(e.cfg.Selectors.Deny, selector) {
+		return "selectors.deny", "function selector " + selector.String() + " is denied"
+	}
+
+	if len(e.cfg.Selectors.Allow) > 0 && !containsSelector(e.cfg.Selectors.Allow, selector) {
+		return "selectors.allow", "function selector " + selector.String() + " is not allowed"
+	}
+
+	return "", ""
+}
+
+func (e *Engine) checkLimits(req *Request, now time.Time) (rule, reason string) {
+	value := req.value()
+
+	for i, limit := range e.cfg.Limits {
+		if !limitApplies(limit, req) {
+			continue
+		}
+
+		name := limitName(limit, i)
+
+		if limit.MaxValue != nil && value.Cmp(limit.MaxValue.Int()) > 0 {
+			return name + ".max_value", "value " + value.String() + " exceeds the maximum of " + limit.MaxValue.String() + " wei"
+		}
+
+		history := e.prune(i, now)
+
+		if limit.DailyValue != nil {
+			spent := new(big.Int)
+			for _, ev := range history {
+				if now.Sub(ev.time) < dailyWindow {
+					spent.Add(spent, ev.value)
+				}
+			}
+
+			if total := new(big.Int).Add(spent, value); total.Cmp(limit.DailyValue.Int()) > 0 {
+				return name + ".daily_value", "value " + value.String() + " would bring the past 24h total to " +
+					total.String() + " wei, exceeding the limit of " + limit.DailyValue.String() + " wei"
+			}
+		}
+
+		if rl := limit.RateLimit; rl != nil {
+			count := 0
+			for _, ev := range history {
+				if now.Sub(ev.time) < time.Duration(rl.Per) {
+					count++
+				}
+			}
+
+			if count >= rl.Requests {
+				return name + ".rate_limit", "rate limit of " + strconv.Itoa(rl.Requests) + " requests per " + rl.Per.String() + " exceeded"
+			}
+		}
+	}
+
+	return "", ""
+}
+
+func (e *Engine) approvalReason(req *Request) string {
+	approval := e.cfg.Approval
+
+	if req.Tx == nil {
+		if approval.Messages {
+			return "message signatures require approval"
+		}
+
+		return ""
+	}
+
+	if approval.ContractCreation && req.Tx.To() == nil {
+		return "contract creation requires approval"
+	}
+
+	if approval.ValueAbove != nil && req.value().Cmp(approval.ValueAbove.Int()) > 0 {
+		return "value " + req.value().String() + " exceeds the approval threshold of " + approval.ValueAbove.String() + " wei"
+	}
+
+	return ""
+}
+
+// record adds an allowed request to the history of every limit it falls under, as pending.
+func (e *Engine) record(req *Request, now time.Time) {
+	for i, limit := range e.cfg.Limits {
+		if limitApplies(limit, req) && (limit.DailyValue != nil || limit.RateLimit != nil) {
+			e.history[i] = append(e.history[i], event{time: now, value: new(big.Int).Set(req.value()), pending: req})
+		}
+	}
+}
+
+// Commit records that req, which was allowed by Check, was signed:
+// it keeps counting towards the policy's daily windows and rate limits.
+func (e *Engine) Commit(req *Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, history := range e.history {
+		for i := range history {
+			if history[i].pending == req {
+				history[i].pending = nil
+			}
+		}
+	}
+}
+
+// Release records that req, which was allowed by Check, wasn't signed after all,
+// so it no longer counts towards the policy's daily windows and rate limits.
+// Committed requests can't be released.
+func (e *Engine) Release(req *Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, history := range e.history {
+		kept := history[:0]
+
+		for _, ev := range history {
+			if ev.pending != req {
+				kept = append(kept, ev)
+			}
+		}
+
+		e.history[i] = kept
+	}
+}
+
+// Done commits req if err is nil, and releases it otherwise.
+// It's meant to be deferred by callers of Check once it allowed req:
+//
+//	if _, err := engine.Check(ctx, req); err != nil {
+//		return nil, err
+//	}
+//
+//	defer func() { engine.Done(req, err) }()
+func (e *Engine) Done(req *Request, err error) {
+	if err != nil {
+		e.Release(req)
+	} else {
+		e.Commit(req)
+	}
+}
+
+// prune drops the events of limit i which are too old to matter, returning the rest.
+func (e *Engine) prune(i int, now time.Time) []event {
+	window := time.Duration(0)
+
+	if e.cfg.Limits[i].DailyValue != nil {
+		window = dailyWindow
+	}
+
+	if rl := e.cfg.Limits[i].RateLimit; rl != nil && time.Duration(rl.Per) > window {
+		window = time.Duration(rl.Per)
+	}
+
+	history := e.history[i]
+
+	keep := 0
+	for keep < len(history) && now.Sub(history[keep].time) >= window {
+		keep++
+	}
+
+	e.history[i] = history[keep:]
+
+	return e.history[i]
+}
+
+func limitApplies(limit Limit, req *Request) bool {
+	if limit.Address != nil && *limit.Address != req.From {
+		return false
+	}
+
+	if limit.ChainID != nil {
+		if req.Tx == nil || req.ChainID == nil || !req.ChainID.IsUint64() || req.ChainID.Uint64() != *limit.ChainID {
+			return false
+		}
+	}
+
+	return true
+}
+
+func txSelector(tx *types.Transaction) (Selector, bool) {
+	var selector Selector
+
+	if tx.To() == nil || len(tx.Data()) < len(selector) {
+		return selector, false
+	}
+
+	copy(selector[:], tx.Data())
+
+	return selector, true
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsSelector(selectors []Selector, selector Selector) bool {
+	for _, s := range selectors {
+		if s == selector {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,527 @@
+package policy_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/policy"
+	"github.com/jalavosus/hdwallet-go/signertest"
+)
+
+const testMnemonic string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+var (
+	testRecipient = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	testDenied    = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	testTransfer  = []byte{0xa9, 0x05, 0x9c, 0xbb, 0x01, 0x02}
+	testApprove   = []byte{0x09, 0x5e, 0xa7, 0xb3}
+)
+
+// clock is a manually advanced clock.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTx(to *common.Address, value int64, data []byte) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		To:       to,
+		Value:    big.NewInt(value),
+		Gas:      100000,
+		GasPrice: big.NewInt(params.GWei),
+		Data:     data,
+	})
+}
+
+func mustEngine(t *testing.T, data string, opts ...policy.EngineOpt) *policy.Engine {
+	t.Helper()
+
+	cfg, err := policy.ParseConfig([]byte(data))
+	require.NoError(t, err)
+
+	engine, err := policy.NewEngine(cfg, opts...)
+	require.NoError(t, err)
+
+	return engine
+}
+
+func TestNewEngine(t *testing.T) {
+	_, err := policy.NewEngine(nil)
+	assert.Error(t, err)
+
+	_, err = policy.NewEngine(&policy.Config{Limits: []policy.Limit{{RateLimit: &policy.RateLimit{}}}})
+	assert.Error(t, err)
+
+	_, err = policy.NewEngine(&policy.Config{})
+	assert.NoError(t, err)
+}
+
+func TestEngine_Check_Lists(t *testing.T) {
+	from := common.HexToAddress("0x01")
+	chainID := big.NewInt(1)
+
+	tests := []struct {
+		name     string
+		config   string
+		tx       *types.Transaction
+		wantRule string
+	}{
+		{
+			name:   "empty policy allows everything",
+			config: "{}",
+			tx:     newTx(&testDenied, 1, testApprove),
+		},
+		{
+			name:     "denied recipient",
+			config:   "recipients: {deny: [0x000000000000000000000000000000000000dEaD]}",
+			tx:       newTx(&testDenied, 1, nil),
+			wantRule: "recipients.deny",
+		},
+		{
+			name:   "recipient not denied",
+			config: "recipients: {deny: [0x000000000000000000000000000000000000dEaD]}",
+			tx:     newTx(&testRecipient, 1, nil),
+		},
+		{
+			name:   "allowed recipient",
+			config: "recipients: {allow: [0x00000000000000000000000000000000000000aa]}",
+			tx:     newTx(&testRecipient, 1, nil),
+		},
+		{
+			name:     "recipient not allowed",
+			config:   "recipients: {allow: [0x00000000000000000000000000000000000000aa]}",
+			tx:       newTx(&testDenied, 1, nil),
+			wantRule: "recipients.allow",
+		},
+		{
+			name:     "contract creation with recipient allow list",
+			config:   "recipients: {allow: [0x00000000000000000000000000000000000000aa]}",
+			tx:       newTx(nil, 0, []byte{0x60, 0x80, 0x60, 0x40}),
+			wantRule: "recipients.allow",
+		},
+		{
+			name:   "allowed selector",
+			config: `selectors: {allow: ["0xa9059cbb"]}`,
+			tx:     newTx(&testRecipient, 0, testTransfer),
+		},
+		{
+			name:     "selector not allowed",
+			config:   `selectors: {allow: ["0xa9059cbb"]}`,
+			tx:       newTx(&testRecipient, 0, testApprove),
+			wantRule: "selectors.allow",
+		},
+		{
+			name:   "plain transfer with selector allow list",
+			config: `selectors: {allow: ["0xa9059cbb"]}`,
+			tx:     newTx(&testRecipient, 1, nil),
+		},
+		{
+			name:     "calldata shorter than a selector with selector allow list",
+			config:   `selectors: {allow: ["0xa9059cbb"]}`,
+			tx:       newTx(&testRecipient, 0, []byte{0xa9, 0x05, 0x9c}),
+			wantRule: "selectors.allow",
+		},
+		{
+			name:   "calldata shorter than a selector with selector deny list",
+			config: `selectors: {deny: ["0x095ea7b3"]}`,
+			tx:     newTx(&testRecipient, 0, []byte{0x09}),
+		},
+		{
+			name:     "denied selector",
+			config:   `selectors: {deny: ["0x095ea7b3"]}`,
+			tx:       newTx(&testRecipient, 0, testApprove),
+			wantRule: "selectors.deny",
+		},
+		{
+			name:   "contract creation with selector deny list",
+			config: `selectors: {deny: ["0x60806040"]}`,
+			tx:     newTx(nil, 0, []byte{0x60, 0x80, 0x60, 0x40}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := mustEngine(t, tt.config)
+
+			decision, err := engine.Check(context.Background(), &policy.Request{From: from, ChainID: chainID, Tx: tt.tx})
+			require.NotNil(t, decision)
+
+			assert.Equal(t, tt.wantRule, decision.Rule)
+			assert.Equal(t, tt.wantRule == "", decision.Allowed)
+
+			if tt.wantRule == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, policy.ErrDenied)
+
+			var denied *policy.DeniedError
+			if assert.True(t, errors.As(err, &denied)) {
+				assert.Equal(t, decision, denied.Decision)
+			}
+		})
+	}
+}
+
+func TestEngine_Check_Limits(t *testing.T) {
+	const config = `
+limits:
+  - name: hot
+    address: 0x0000000000000000000000000000000000000001
+    chain_id: 1
+    max_value: 10 wei
+    daily_value: 25 wei
+  - name: rate
+    chain_id: 1
+    rate_limit: {requests: 3, per: 1m}
+`
+
+	hot := common.HexToAddress("0x01")
+	cold := common.HexToAddress("0x02")
+	mainnet, goerli := big.NewInt(1), big.NewInt(5)
+
+	c := &clock{now: time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)}
+	engine := mustEngine(t, config, policy.WithClock(c.Now))
+
+	check := func(from common.Address, chainID *big.Int, value int64) string {
+		decision, _ := engine.Check(context.Background(), &policy.Request{From: from, ChainID: chainID, Tx: newTx(&testRecipient, value, nil)})
+		return decision.Rule
+	}
+
+	// per-transaction maximum only applies to the limit's address and chain
+	assert.Equal(t, "hot.max_value", check(hot, mainnet, 11))
+	assert.Equal(t, "", check(cold, goerli, 11))
+	assert.Equal(t, "", check(hot, goerli, 11))
+
+	// daily window: 10 + 10 allowed, a further 10 would exceed 25
+	assert.Equal(t, "", check(hot, mainnet, 10))
+	c.advance(time.Hour)
+	assert.Equal(t, "", check(hot, mainnet, 10))
+	c.advance(time.Hour)
+	assert.Equal(t, "hot.daily_value", check(hot, mainnet, 10))
+
+	// the rate limit is shared by every address on chain 1: 2 requests so far
+	assert.Equal(t, "", check(cold, mainnet, 100))
+	assert.Equal(t, "", check(cold, mainnet, 100))
+	assert.Equal(t, "", check(cold, mainnet, 100))
+	assert.Equal(t, "rate.rate_limit", check(cold, mainnet, 100))
+	assert.Equal(t, "", check(cold, goerli, 100))
+
+	c.advance(time.Minute)
+	assert.Equal(t, "", check(hot, mainnet, 5))
+
+	// the first 10 wei leave the daily window 24h after being spent
+	c.advance(22*time.Hour - 2*time.Minute)
+	assert.Equal(t, "hot.daily_value", check(hot, mainnet, 10))
+	c.advance(time.Minute)
+	assert.Equal(t, "", check(hot, mainnet, 10))
+}
+
+func TestEngine_Check_Approval(t *testing.T) {
+	const config = `
+approval:
+  value_above: 1 ether
+  contract_creation: true
+  messages: true
+`
+
+	from := common.HexToAddress("0x01")
+
+	var (
+		reasons []string
+		approve bool
+	)
+
+	approvalFunc := func(_ context.Context, _ *policy.Request, reason string) (bool, error) {
+		reasons = append(reasons, reason)
+		return approve, nil
+	}
+
+	tests := []struct {
+		name             string
+		req              *policy.Request
+		approve          bool
+		wantApprovalCall bool
+		wantAllowed      bool
+	}{
+		{
+			name:        "below threshold",
+			req:         &policy.Request{From: from, ChainID: big.NewInt(1), Tx: newTx(&testRecipient, params.Ether, nil)},
+			wantAllowed: true,
+		},
+		{
+			name:             "above threshold approved",
+			req:              &policy.Request{From: from, ChainID: big.NewInt(1), Tx: newTx(&testRecipient, params.Ether+1, nil)},
+			approve:          true,
+			wantApprovalCall: true,
+			wantAllowed:      true,
+		},
+		{
+			name:             "above threshold rejected",
+			req:              &policy.Request{From: from, ChainID: big.NewInt(1), Tx: newTx(&testRecipient, params.Ether+1, nil)},
+			wantApprovalCall: true,
+		},
+		{
+			name:             "contract creation",
+			req:              &policy.Request{From: from, ChainID: big.NewInt(1), Tx: newTx(nil, 0, []byte{0x60})},
+			approve:          true,
+			wantApprovalCall: true,
+			wantAllowed:      true,
+		},
+		{
+			name:             "message",
+			req:              &policy.Request{From: from, Message: []byte("hello")},
+			wantApprovalCall: true,
+		},
+	}
+
+	engine := mustEngine(t, config, policy.WithApprovalFunc(approvalFunc))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons, approve = nil, tt.approve
+
+			decision, err := engine.Check(context.Background(), tt.req)
+			require.NotNil(t, decision)
+
+			assert.Equal(t, tt.wantApprovalCall, len(reasons) == 1)
+			assert.Equal(t, tt.wantApprovalCall, decision.ApprovalRequired)
+			assert.Equal(t, tt.wantAllowed, decision.Allowed)
+			assert.Equal(t, tt.wantAllowed, err == nil)
+
+			if tt.wantApprovalCall {
+				assert.Equal(t, tt.approve, decision.Approved)
+				assert.Contains(t, decision.Reason, reasons[0])
+			}
+		})
+	}
+
+	t.Run("no approval function", func(t *testing.T) {
+		engine := mustEngine(t, config)
+
+		_, err := engine.Check(context.Background(), &policy.Request{From: from, Message: []byte("hello")})
+		assert.ErrorIs(t, err, policy.ErrDenied)
+	})
+
+	t.Run("approval error", func(t *testing.T) {
+		engine := mustEngine(t, config, policy.WithApprovalFunc(func(context.Context, *policy.Request, string) (bool, error) {
+			return false, errors.New("operator unreachable")
+		}))
+
+		decision, err := engine.Check(context.Background(), &policy.Request{From: from, Message: []byte("hello")})
+		require.Error(t, err)
+
+		assert.NotErrorIs(t, err, policy.ErrDenied)
+		assert.False(t, decision.Allowed)
+	})
+}
+
+func TestEngine_DecisionLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	engine := mustEngine(
+		t, "recipients: {deny: [0x000000000000000000000000000000000000dEaD]}",
+		policy.WithDecisionLogger(policy.NewJSONDecisionLogger(&buf)),
+	)
+
+	from := common.HexToAddress("0x01")
+
+	_, err := engine.Check(context.Background(), &policy.Request{From: from, ChainID: big.NewInt(1), Tx: newTx(&testRecipient, 7, testTransfer)})
+	require.NoError(t, err)
+
+	_, err = engine.Check(context.Background(), &policy.Request{From: from, ChainID: big.NewInt(1), Tx: newTx(&testDenied, 1, nil)})
+	require.Error(t, err)
+
+	_, err = engine.Check(context.Background(), &policy.Request{From: from, Message: []byte("hello")})
+	require.NoError(t, err)
+
+	dec := json.NewDecoder(&buf)
+
+	var decisions []policy.Decision
+
+	for dec.More() {
+		var decision policy.Decision
+		require.NoError(t, dec.Decode(&decision))
+
+		decisions = append(decisions, decision)
+	}
+
+	require.Len(t, decisions, 3)
+
+	assert.Equal(t, policy.KindTransaction, decisions[0].Kind)
+	assert.True(t, decisions[0].Allowed)
+	assert.Equal(t, &testRecipient, decisions[0].To)
+	assert.Equal(t, big.NewInt(7), decisions[0].Value)
+	assert.Equal(t, "0xa9059cbb", decisions[0].Selector)
+	assert.Equal(t, big.NewInt(1), decisions[0].ChainID)
+
+	assert.False(t, decisions[1].Allowed)
+	assert.Equal(t, "recipients.deny", decisions[1].Rule)
+	assert.Contains(t, decisions[1].Reason, testDenied.Hex())
+
+	assert.Equal(t, policy.KindMessage, decisions[2].Kind)
+	assert.True(t, decisions[2].Allowed)
+	assert.Len(t, decisions[2].MessageHash, 32)
+	assert.Nil(t, decisions[2].To)
+}
+
+func TestEngine_Signers(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	addr, err := w.DeriveAddressFromIndex(0)
+	require.NoError(t, err)
+
+	chainID := big.NewInt(1337)
+
+	engine := mustEngine(t, "recipients: {deny: [0x000000000000000000000000000000000000dEaD]}")
+
+	t.Run("TransactOpts", func(t *testing.T) {
+		opts, err := engine.TransactOpts(addr, chainID)
+		require.NoError(t, err)
+
+		assert.Equal(t, addr.Address(), opts.From)
+
+		signed, err := opts.Signer(opts.From, newTx(&testRecipient, 1, nil))
+		require.NoError(t, err)
+
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		require.NoError(t, err)
+		assert.Equal(t, addr.Address(), sender)
+
+		_, err = opts.Signer(opts.From, newTx(&testDenied, 1, nil))
+		assert.ErrorIs(t, err, policy.ErrDenied)
+
+		// the address' own transactor is left unchecked
+		unchecked, err := addr.TransactOptsForChainID(chainID)
+		require.NoError(t, err)
+
+		_, err = unchecked.Signer(unchecked.From, newTx(&testDenied, 1, nil))
+		assert.NoError(t, err)
+	})
+
+	t.Run("SignTx", func(t *testing.T) {
+		signed, err := engine.SignTx(context.Background(), addr, newTx(&testRecipient, 1, nil), chainID)
+		require.NoError(t, err)
+
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		require.NoError(t, err)
+		assert.Equal(t, addr.Address(), sender)
+
+		_, err = engine.SignTx(context.Background(), addr, newTx(&testDenied, 1, nil), chainID)
+		assert.ErrorIs(t, err, policy.ErrDenied)
+	})
+}
+
+func TestEngine_CommitRelease(t *testing.T) {
+	const config = `
+limits:
+  - name: spend
+    daily_value: 10 wei
+    rate_limit: {requests: 2, per: 1h}
+`
+
+	from := common.HexToAddress("0x01")
+	engine := mustEngine(t, config)
+
+	newReq := func(value int64) *policy.Request {
+		return &policy.Request{From: from, ChainID: big.NewInt(1), Tx: newTx(&testRecipient, value, nil)}
+	}
+
+	// pending requests count towards limits, so concurrent requests can't exceed them
+	first := newReq(6)
+	_, err := engine.Check(context.Background(), first)
+	require.NoError(t, err)
+
+	decision, err := engine.Check(context.Background(), newReq(6))
+	assert.ErrorIs(t, err, policy.ErrDenied)
+	assert.Equal(t, "spend.daily_value", decision.Rule)
+
+	// released requests no longer count
+	engine.Release(first)
+
+	second := newReq(6)
+	_, err = engine.Check(context.Background(), second)
+	require.NoError(t, err)
+
+	// committed requests keep counting, and can't be released
+	engine.Commit(second)
+	engine.Release(second)
+
+	_, err = engine.Check(context.Background(), newReq(6))
+	assert.ErrorIs(t, err, policy.ErrDenied)
+
+	third := newReq(1)
+	_, err = engine.Check(context.Background(), third)
+	require.NoError(t, err)
+
+	engine.Done(third, errors.New("signing failed"))
+
+	fourth := newReq(1)
+	_, err = engine.Check(context.Background(), fourth)
+	require.NoError(t, err)
+
+	engine.Done(fourth, nil)
+
+	decision, err = engine.Check(context.Background(), newReq(1))
+	assert.ErrorIs(t, err, policy.ErrDenied)
+	assert.Equal(t, "spend.rate_limit", decision.Rule)
+}
+
+func TestEngine_SignTx_Denied(t *testing.T) {
+	mock, err := signertest.NewRandom()
+	require.NoError(t, err)
+
+	engine := mustEngine(t, "recipients: {deny: [0x000000000000000000000000000000000000dEaD]}")
+
+	_, err = engine.SignTx(context.Background(), mock, newTx(&testDenied, 1, nil), big.NewInt(1))
+	assert.ErrorIs(t, err, policy.ErrDenied)
+
+	// denied transactions never reach the signer
+	assert.Empty(t, mock.Transactions())
+
+	_, err = engine.SignTx(context.Background(), mock, newTx(&testRecipient, 1, nil), big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Len(t, mock.Transactions(), 1)
+}
+
+func TestEngine_SignTx_SigningFails(t *testing.T) {
+	mock, err := signertest.NewRandom()
+	require.NoError(t, err)
+
+	engine := mustEngine(t, "limits: [{rate_limit: {requests: 1, per: 1h}}]")
+
+	// transactions which fail to sign don't count towards the rate limit
+	mock.SetError(errors.New("device disconnected"))
+
+	_, err = engine.SignTx(context.Background(), mock, newTx(&testRecipient, 1, nil), big.NewInt(1))
+	assert.ErrorContains(t, err, "device disconnected")
+
+	mock.SetError(nil)
+
+	_, err = engine.SignTx(context.Background(), mock, newTx(&testRecipient, 1, nil), big.NewInt(1))
+	assert.NoError(t, err)
+
+	_, err = engine.SignTx(context.Background(), mock, newTx(&testRecipient, 1, nil), big.NewInt(1))
+	assert.ErrorIs(t, err, policy.ErrDenied)
+}
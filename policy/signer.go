@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+// TransactOpts returns a *bind.TransactOpts for chainID (see hdwallet.NewTransactOpts)
+// whose signer checks every transaction against the policy before signing it using signer.
+// Checks use the returned options' Context, if set.
+func (e *Engine) TransactOpts(signer hdwallet.Signer, chainID *big.Int) (*bind.TransactOpts, error) {
+	opts, err := hdwallet.NewTransactOpts(signer, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	sign := opts.Signer
+	chainID = new(big.Int).Set(chainID)
+
+	opts.Signer = func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		ctx := opts.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		req := &Request{From: from, ChainID: chainID, Tx: tx}
+		if _, err := e.Check(ctx, req); err != nil {
+			return nil, err
+		}
+
+		signed, err := sign(from, tx)
+		e.Done(req, err)
+
+		return signed, err
+	}
+
+	return opts, nil
+}
+
+// SignTx checks tx against the policy, signing it for chainID using signer if allowed.
+func (e *Engine) SignTx(ctx context.Context, signer hdwallet.Signer, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	req := &Request{From: signer.Address(), ChainID: chainID, Tx: tx}
+	if _, err := e.Check(ctx, req); err != nil {
+		return nil, err
+	}
+
+	signed, err := signer.SignTx(tx, chainID)
+	e.Done(req, err)
+
+	return signed, err
+}
@@ -0,0 +1,132 @@
+package hdwallet
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// addressRegistry holds an account's derived and imported addresses in the order they were first added,
+// indexed by address and derivation path.
+// Each derivation path and imported address is only ever recorded once.
+type addressRegistry struct {
+	mu        sync.RWMutex
+	addrs     []*HDWalletAddress
+	byAddress map[common.Address]*HDWalletAddress
+	byPath    map[string]*HDWalletAddress
+}
+
+func newAddressRegistry() *addressRegistry {
+	return &addressRegistry{
+		byAddress: make(map[common.Address]*HDWalletAddress),
+		byPath:    make(map[string]*HDWalletAddress),
+	}
+}
+
+// add records addr, returning the previously recorded address
+// with the same derivation path (or, for imported addresses, the same address) if there is one.
+func (r *addressRegistry) add(addr *HDWalletAddress) *HDWalletAddress {
+	path := addr.DerivationPath()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if addr.imported {
+		if existing, ok := r.byAddress[addr.address]; ok {
+			return existing
+		}
+	} else {
+		if existing, ok := r.byPath[path]; ok {
+			return existing
+		}
+
+		r.byPath[path] = addr
+	}
+
+	r.addrs = append(r.addrs, addr)
+
+	if _, ok := r.byAddress[addr.address]; !ok {
+		r.byAddress[addr.address] = addr
+	}
+
+	return addr
+}
+
+func (r *addressRegistry) all() []*HDWalletAddress {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addrs := make([]*HDWalletAddress, len(r.addrs))
+	copy(addrs, r.addrs)
+
+	return addrs
+}
+
+func (r *addressRegistry) findByAddress(address common.Address) (*HDWalletAddress, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addr, ok := r.byAddress[address]
+
+	return addr, ok
+}
+
+func (r *addressRegistry) findByPath(path accounts.DerivationPath) (*HDWalletAddress, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addr, ok := r.byPath[path.String()]
+
+	return addr, ok
+}
+
+// FindByAddress returns the address matching address, if it has been derived
+// using DeriveAddress, DeriveHardenedAddress, DeriveAddressFromIndex or DeriveRange,
+// or imported using ImportAddress.
+func (w *HDWallet) FindByAddress(address common.Address) (*HDWalletAddress, bool) {
+	return w.account.derivedAddrs.findByAddress(address)
+}
+
+// FindByPath returns the derived address at the passed absolute derivation path
+// (for example "m/44'/60'/0'/0/1"), or at a path relative to the wallet's
+// m/44'/coin_type'/0'/0 (for example "1", see ParseDerivationPath), if it has been derived
+// using DeriveAddress, DeriveHardenedAddress, DeriveAddressFromIndex or DeriveRange.
+func (w *HDWallet) FindByPath(path string) (*HDWalletAddress, bool) {
+	derivationPath, err := ParseDerivationPath(w.coinType, path)
+	if err != nil {
+		return nil, false
+	}
+
+	return w.account.derivedAddrs.findByPath(derivationPath)
+}
+
+// ImportAddress attaches an address created from a raw private key using NewAddressFromPrivateKey
+// to the wallet, alongside its derived addresses.
+// Imported addresses are returned by Accounts() and FindByAddress, and are flagged as
+// imported in exports, but aren't restored along with the wallet's mnemonic.
+// If the address has already been imported or derived, the existing address is returned.
+// An address can only be imported into one wallet.
+func (w *HDWallet) ImportAddress(addr *HDWalletAddress) (*HDWalletAddress, error) {
+	if addr == nil || !addr.imported {
+		return nil, errors.New("only addresses created from raw private keys can be imported")
+	}
+
+	if existing, ok := w.account.derivedAddrs.findByAddress(addr.address); ok {
+		return existing, nil
+	}
+
+	// the address' private key is kept in memory owned by its wallet.
+	if addr.vault != nil && addr.vault != w.vault {
+		return nil, errors.New("address already belongs to another wallet")
+	}
+
+	if err := w.vault.register(addr.privateKey); err != nil {
+		return nil, err
+	}
+
+	addr.vault = w.vault
+
+	return w.account.derivedAddrs.add(addr), nil
+}
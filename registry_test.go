@@ -0,0 +1,84 @@
+package hdwallet_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+func TestHDWallet_Registry(t *testing.T) {
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonicZero))
+	require.NoError(t, err)
+
+	first, err := w.DeriveAddressFromIndex(2)
+	require.NoError(t, err)
+
+	again, err := w.DeriveAddressFromIndex(2)
+	require.NoError(t, err)
+
+	assert.Same(t, first, again, "re-deriving an index should return the recorded address")
+
+	_, err = w.DeriveRange(0, 4)
+	require.NoError(t, err)
+
+	hardened, err := w.DeriveAddressFromIndex(hdkeychain.HardenedKeyStart + 1)
+	require.NoError(t, err)
+
+	var gotIndices []int
+	for _, addr := range w.Accounts() {
+		gotIndices = append(gotIndices, addr.HardenedDerivationIndex())
+	}
+
+	assert.Equal(t, []int{2, 0, 1, 3, hdkeychain.HardenedKeyStart + 1}, gotIndices)
+
+	tests := []struct {
+		name    string
+		address common.Address
+		path    string
+		want    *hdwallet.HDWalletAddress
+		wantOk  bool
+	}{
+		{
+			"non-hardened",
+			common.HexToAddress("0xb6716976A3ebe8D39aCEB04372f22Ff8e6802D7A"),
+			"m/44'/60'/0'/0/2",
+			first,
+			true,
+		},
+		{
+			"hardened",
+			hardened.Address(),
+			"m/44'/60'/0'/0/1'",
+			hardened,
+			true,
+		},
+		{
+			"not derived",
+			common.HexToAddress("0x51cA8ff9f1C0a99f88E86B8112eA3237F55374cA"),
+			"m/44'/60'/0'/0/4",
+			nil,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := w.FindByAddress(tt.address)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Same(t, tt.want, got)
+
+			got, ok = w.FindByPath(tt.path)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Same(t, tt.want, got)
+		})
+	}
+
+	for _, path := range []string{"", "0/2", "m/44'/60'/0'/0/x"} {
+		_, ok := w.FindByPath(path)
+		assert.False(t, ok, "FindByPath(%q)", path)
+	}
+}
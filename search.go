@@ -0,0 +1,409 @@
+package hdwallet
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// searchBatchSize is the number of address indices handed to a search worker at once.
+const searchBatchSize int = 256
+
+// SearchResult is an address found by SearchMnemonic.
+type SearchResult struct {
+	Address        common.Address
+	DerivationPath accounts.DerivationPath
+	AccountIndex   int
+	AddressIndex   int
+	Hardened       bool
+}
+
+// SearchProgress reports how many of the addresses in a search's range have been checked.
+type SearchProgress struct {
+	Checked uint64
+	Total   uint64
+}
+
+type searchOpts struct {
+	workers      int
+	coinType     CoinType
+	accountStart int
+	accountEnd   int
+	indexStart   int
+	indexEnd     int
+	hardened     bool
+	nonHardened  bool
+	onProgress   func(SearchProgress)
+}
+
+type funcSearchOpt struct {
+	f func(*searchOpts)
+}
+
+func newFuncSearchOpt(f func(*searchOpts)) *funcSearchOpt {
+	return &funcSearchOpt{f}
+}
+
+func (fo *funcSearchOpt) apply(opts *searchOpts) {
+	fo.f(opts)
+}
+
+type SearchOpt interface {
+	apply(*searchOpts)
+}
+
+// WithSearchWorkers sets the number of goroutines deriving addresses.
+// Defaults to runtime.NumCPU().
+func WithSearchWorkers(workers int) SearchOpt {
+	return newFuncSearchOpt(func(opts *searchOpts) {
+		opts.workers = workers
+	})
+}
+
+// WithSearchCoinType sets the SLIP-44 coin type used in searched derivation paths.
+// Defaults to CoinTypeEthereum.
+func WithSearchCoinType(coinType CoinType) SearchOpt {
+	return newFuncSearchOpt(func(opts *searchOpts) {
+		opts.coinType = coinType
+	})
+}
+
+// WithSearchAccounts sets the range [start, end) of account indices to search.
+// Defaults to [0, 1).
+func WithSearchAccounts(start, end int) SearchOpt {
+	return newFuncSearchOpt(func(opts *searchOpts) {
+		opts.accountStart = start
+		opts.accountEnd = end
+	})
+}
+
+// WithSearchIndices sets the range [start, end) of address indices to search in each account.
+// Defaults to [0, 1000).
+func WithSearchIndices(start, end int) SearchOpt {
+	return newFuncSearchOpt(func(opts *searchOpts) {
+		opts.indexStart = start
+		opts.indexEnd = end
+	})
+}
+
+// WithSearchHardened sets whether hardened and non-hardened address indices are searched.
+// Defaults to only searching non-hardened indices.
+func WithSearchHardened(hardened, nonHardened bool) SearchOpt {
+	return newFuncSearchOpt(func(opts *searchOpts) {
+		opts.hardened = hardened
+		opts.nonHardened = nonHardened
+	})
+}
+
+// WithSearchProgress sets a callback which is periodically passed the search's progress.
+// Calls are never made concurrently, and Checked never decreases between calls.
+func WithSearchProgress(onProgress func(SearchProgress)) SearchOpt {
+	return newFuncSearchOpt(func(opts *searchOpts) {
+		opts.onProgress = onProgress
+	})
+}
+
+func defaultSearchOpts() *searchOpts {
+	return &searchOpts{
+		workers:     runtime.NumCPU(),
+		coinType:    CoinTypeEthereum,
+		accountEnd:  1,
+		indexEnd:    1000,
+		nonHardened: true,
+	}
+}
+
+func (o *searchOpts) validate() error {
+	if o.workers < 1 {
+		return errors.Errorf("invalid number of search workers %d", o.workers)
+	}
+
+	if o.accountStart < 0 || o.accountEnd < o.accountStart || isHardenedIdx(o.accountEnd-1) {
+		return errors.Errorf("invalid account range [%d, %d)", o.accountStart, o.accountEnd)
+	}
+
+	if o.indexStart < 0 || o.indexEnd < o.indexStart || isHardenedIdx(o.indexEnd-1) {
+		return errors.Errorf("invalid address index range [%d, %d)", o.indexStart, o.indexEnd)
+	}
+
+	if !o.hardened && !o.nonHardened {
+		return errors.New("search must include hardened or non-hardened indices")
+	}
+
+	return nil
+}
+
+type searchJob struct {
+	chainKey   *hdkeychain.ExtendedKey
+	chainPath  accounts.DerivationPath
+	accountIdx int
+	start, end int
+	hardened   bool
+}
+
+// SearchMnemonicForAddress searches the wallet described by mnemonic and passphrase
+// for target, returning as soon as it is found.
+func SearchMnemonicForAddress(ctx context.Context, mnemonic, passphrase string, target common.Address, opts ...SearchOpt) (*SearchResult, bool, error) {
+	results, err := SearchMnemonic(ctx, mnemonic, passphrase, []common.Address{target}, opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(results) == 0 {
+		return nil, false, nil
+	}
+
+	return results[0], true, nil
+}
+
+// SearchMnemonic searches the wallet described by mnemonic and passphrase for every address in targets
+// using a pool of workers, returning every hit ordered by account, hardened flag and address index.
+// The search stops early once every target has been found,
+// and returns ctx.Err() along with any hits found so far if ctx is cancelled.
+func SearchMnemonic(ctx context.Context, mnemonic, passphrase string, targets []common.Address, opts ...SearchOpt) ([]*SearchResult, error) {
+	sOpts := defaultSearchOpts()
+	for _, o := range opts {
+		o.apply(sOpts)
+	}
+
+	if err := sOpts.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	wallet, err := NewHDWallet(WithPassphrase(passphrase), WithMnemonic(mnemonic))
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[common.Address]struct{}, len(targets))
+	for _, t := range targets {
+		remaining[t] = struct{}{}
+	}
+
+	var (
+		jobs  []searchJob
+		total uint64
+	)
+
+	err = wallet.vault.withMaster(func(masterKey *hdkeychain.ExtendedKey) (err error) {
+		jobs, total, err = sOpts.jobs(masterKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		progressMu sync.Mutex
+		checked    uint64
+		results    []*SearchResult
+		searchErr  error
+		jobsCh     = make(chan searchJob)
+	)
+
+	for i := 0; i < sOpts.workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range jobsCh {
+				hits, err := job.run(ctx, remaining, &mu)
+
+				mu.Lock()
+				results = append(results, hits...)
+				if err != nil && searchErr == nil {
+					searchErr = err
+				}
+				done := len(remaining) == 0 || searchErr != nil
+				mu.Unlock()
+
+				if sOpts.onProgress != nil {
+					// count and report under the same lock, so Checked never goes backwards.
+					progressMu.Lock()
+					checked += uint64(job.end - job.start)
+					sOpts.onProgress(SearchProgress{Checked: checked, Total: total})
+					progressMu.Unlock()
+				}
+
+				if done {
+					cancel()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, job := range jobs {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobsCh <- job:
+		}
+	}
+
+	close(jobsCh)
+	wg.Wait()
+
+	zeroSearchJobs(jobs)
+
+	sortSearchResults(results)
+
+	if searchErr != nil {
+		return results, searchErr
+	}
+
+	mu.Lock()
+	found := len(remaining) == 0
+	mu.Unlock()
+
+	// the search context is cancelled once every target has been found,
+	// so only report cancellation of the caller's context.
+	if !found {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// zeroSearchJobs zeroes the chain keys shared by jobs.
+func zeroSearchJobs(jobs []searchJob) {
+	for _, job := range jobs {
+		job.chainKey.Zero()
+	}
+}
+
+// jobs splits the search range into batches, deriving the chain-level key
+// (m/44'/coin_type'/account'/0) of each searched account once.
+func (o *searchOpts) jobs(masterKey *hdkeychain.ExtendedKey) ([]searchJob, uint64, error) {
+	var (
+		jobs  []searchJob
+		total uint64
+	)
+
+	for accountIdx := o.accountStart; accountIdx < o.accountEnd; accountIdx++ {
+		firstPath, err := addressDerivationPathFromIdx(o.coinType, accountIdx, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		chainPath := firstPath[:len(firstPath)-1]
+
+		chainKey, err := deriveExtendedKey(masterKey, chainPath, false)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, hardened := range []bool{false, true} {
+			if (hardened && !o.hardened) || (!hardened && !o.nonHardened) {
+				continue
+			}
+
+			for start := o.indexStart; start < o.indexEnd; start += searchBatchSize {
+				end := start + searchBatchSize
+				if end > o.indexEnd {
+					end = o.indexEnd
+				}
+
+				jobs = append(jobs, searchJob{
+					chainKey:   chainKey,
+					chainPath:  chainPath,
+					accountIdx: accountIdx,
+					start:      start,
+					end:        end,
+					hardened:   hardened,
+				})
+
+				total += uint64(end - start)
+			}
+		}
+	}
+
+	return jobs, total, nil
+}
+
+func (j searchJob) run(ctx context.Context, remaining map[common.Address]struct{}, mu *sync.Mutex) ([]*SearchResult, error) {
+	var hits []*SearchResult
+
+	// hdkeychain.ExtendedKey lazily caches its public key, so each job
+	// works on its own copy of the shared chain key, which it can zero once done.
+	chainKey, err := copyExtendedKey(nil, j.chainKey)
+	if err != nil {
+		return nil, err
+	}
+
+	defer chainKey.Zero()
+
+	for idx := j.start; idx < j.end; idx++ {
+		if ctx.Err() != nil {
+			return hits, nil
+		}
+
+		childIdx := uint32(idx)
+		if j.hardened {
+			childIdx += hdkeychain.HardenedKeyStart
+		}
+
+		address, err := childAddress(chainKey, childIdx)
+		if err != nil {
+			return hits, err
+		}
+
+		mu.Lock()
+		_, ok := remaining[address]
+		if ok {
+			delete(remaining, address)
+		}
+		mu.Unlock()
+
+		if !ok {
+			continue
+		}
+
+		path := make(accounts.DerivationPath, 0, len(j.chainPath)+1)
+		path = append(path, j.chainPath...)
+
+		hits = append(hits, &SearchResult{
+			Address:        address,
+			DerivationPath: append(path, childIdx),
+			AccountIndex:   j.accountIdx,
+			AddressIndex:   idx,
+			Hardened:       j.hardened,
+		})
+	}
+
+	return hits, nil
+}
+
+func sortSearchResults(results []*SearchResult) {
+	sort.Slice(results, func(i, k int) bool {
+		a, b := results[i], results[k]
+
+		if a.AccountIndex != b.AccountIndex {
+			return a.AccountIndex < b.AccountIndex
+		}
+
+		if a.Hardened != b.Hardened {
+			return !a.Hardened
+		}
+
+		return a.AddressIndex < b.AddressIndex
+	})
+}
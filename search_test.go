@@ -0,0 +1,114 @@
+package hdwallet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+func TestSearchMnemonic(t *testing.T) {
+	var (
+		addrA0   = addressAtPath(t, testMnemonicZero, "m/44'/60'/0'/0/3")
+		addrA2   = addressAtPath(t, testMnemonicZero, "m/44'/60'/2'/0/700")
+		addrA1H  = addressAtPath(t, testMnemonicZero, "m/44'/60'/1'/0/42'")
+		notFound = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	)
+
+	tests := []struct {
+		name      string
+		targets   []common.Address
+		opts      []hdwallet.SearchOpt
+		wantPaths []string
+		wantErr   assert.ErrorAssertionFunc
+	}{
+		{
+			"single target",
+			[]common.Address{addrA0},
+			nil,
+			[]string{"m/44'/60'/0'/0/3"},
+			assert.NoError,
+		},
+		{
+			"multiple targets across accounts",
+			[]common.Address{addrA2, addrA0, addrA1H, notFound},
+			[]hdwallet.SearchOpt{
+				hdwallet.WithSearchAccounts(0, 3),
+				hdwallet.WithSearchIndices(0, 1000),
+				hdwallet.WithSearchHardened(true, true),
+				hdwallet.WithSearchWorkers(4),
+			},
+			[]string{"m/44'/60'/0'/0/3", "m/44'/60'/1'/0/42'", "m/44'/60'/2'/0/700"},
+			assert.NoError,
+		},
+		{
+			"hardened indices excluded by default",
+			[]common.Address{addrA1H},
+			[]hdwallet.SearchOpt{hdwallet.WithSearchAccounts(0, 2), hdwallet.WithSearchIndices(0, 50)},
+			nil,
+			assert.NoError,
+		},
+		{
+			"invalid workers",
+			[]common.Address{addrA0},
+			[]hdwallet.SearchOpt{hdwallet.WithSearchWorkers(0)},
+			nil,
+			assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hdwallet.SearchMnemonic(context.Background(), testMnemonicZero, "", tt.targets, tt.opts...)
+			if !tt.wantErr(t, err, fmt.Sprintf("SearchMnemonic(%v)", tt.targets)) || err != nil {
+				return
+			}
+
+			var gotPaths []string
+			for _, res := range got {
+				gotPaths = append(gotPaths, res.DerivationPath.String())
+			}
+
+			assert.Equal(t, tt.wantPaths, gotPaths)
+		})
+	}
+}
+
+func TestSearchMnemonic_Progress(t *testing.T) {
+	var last hdwallet.SearchProgress
+
+	_, err := hdwallet.SearchMnemonic(
+		context.Background(),
+		testMnemonicZero,
+		"",
+		[]common.Address{common.HexToAddress("0x000000000000000000000000000000000000dEaD")},
+		hdwallet.WithSearchIndices(0, 600),
+		hdwallet.WithSearchWorkers(8),
+		hdwallet.WithSearchProgress(func(p hdwallet.SearchProgress) {
+			assert.GreaterOrEqual(t, p.Checked, last.Checked)
+			last = p
+		}),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(600), last.Total)
+	assert.Equal(t, uint64(600), last.Checked)
+}
+
+func TestSearchMnemonic_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := hdwallet.SearchMnemonic(
+		ctx,
+		testMnemonicZero,
+		"",
+		[]common.Address{common.HexToAddress("0x000000000000000000000000000000000000dEaD")},
+		hdwallet.WithSearchIndices(0, 100_000),
+	)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
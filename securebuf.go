@@ -0,0 +1,129 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"math/big"
+	"unsafe"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/pkg/errors"
+)
+
+const (
+	privateKeyLen = 32
+	// extendedKeyLen is the length of an extended private key's key and chain code.
+	extendedKeyLen = privateKeyLen + 32
+)
+
+// ErrMemoryNotLocked is returned by wallets created using WithStrictMemoryLock
+// when their secrets can't be locked into RAM.
+var ErrMemoryNotLocked = errors.New("secrets can't be locked into memory")
+
+// MemoryLocked reports whether every secret of the wallet is held in memory which is
+// locked into RAM and excluded from core dumps. It's false on platforms other than Linux,
+// and when locking memory failed, for example because RLIMIT_MEMLOCK was reached,
+// in which case secrets are kept in memory which may be swapped to disk
+// (see WithStrictMemoryLock).
+func (w HDWallet) MemoryLocked() bool {
+	return !w.vault.memoryNotLocked.Load()
+}
+
+// secureBuffer holds a secret in memory which is kept out of swap and core dumps
+// where the platform supports it (see secureAlloc).
+// Its contents must be copied before being handed out, as destroy releases the memory.
+type secureBuffer struct {
+	b      []byte
+	free   func()
+	locked bool // the memory is locked into RAM
+}
+
+// newSecureBuffer allocates a zeroed secureBuffer of size bytes.
+// If the memory can't be locked into RAM, it either fails with ErrMemoryNotLocked
+// if requireLock is set, or falls back to memory which isn't locked.
+func newSecureBuffer(size int, requireLock bool) (*secureBuffer, error) {
+	s, err := secureAlloc(size, requireLock)
+	if err != nil {
+		return nil, errors.Wrap(err, "error allocating secure memory")
+	}
+
+	return s, nil
+}
+
+// bytes returns the buffer's memory, which is nil for nil buffers.
+func (s *secureBuffer) bytes() []byte {
+	if s == nil {
+		return nil
+	}
+
+	return s.b
+}
+
+// wipe zeroes the buffer, keeping it allocated.
+func (s *secureBuffer) wipe() {
+	wipeBytes(s.bytes())
+}
+
+// destroy wipes and releases the buffer.
+func (s *secureBuffer) destroy() {
+	if s == nil || s.free == nil {
+		return
+	}
+
+	s.free()
+	s.b, s.free = nil, nil
+}
+
+// moveToSecureBuffer makes key's private scalar use buf, which must be privateKeyLen bytes long,
+// wiping its previous memory. key.D keeps using buf as long as it's only set to
+// values which fit in it, which is true of every secp256k1 private key.
+func moveToSecureBuffer(key *ecdsa.PrivateKey, buf *secureBuffer) {
+	words := unsafe.Slice((*big.Word)(unsafe.Pointer(&buf.b[0])), len(buf.b)/int(unsafe.Sizeof(big.Word(0))))
+
+	d := key.D.FillBytes(make([]byte, privateKeyLen))
+	defer wipeBytes(d)
+
+	wipeBigInt(key)
+
+	key.D.SetBits(words[:0]).SetBytes(d)
+}
+
+// copyExtendedKey returns a copy of the extended private key k whose key and chain code
+// are stored in b, which must be extendedKeyLen bytes long, or newly allocated if b is nil.
+// The copy's public key is precomputed, as hdkeychain.ExtendedKey lazily computes and
+// caches it the first time it's needed (including when deriving children),
+// which would otherwise race when deriving from the copy concurrently.
+func copyExtendedKey(b []byte, k *hdkeychain.ExtendedKey) (*hdkeychain.ExtendedKey, error) {
+	if b == nil {
+		b = make([]byte, extendedKeyLen)
+	}
+
+	privKey, err := k.ECPrivKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading extended private key")
+	}
+
+	privKey.Key.PutBytesUnchecked(b[:privateKeyLen])
+	privKey.Zero()
+
+	copy(b[privateKeyLen:], k.ChainCode())
+
+	parentFP := make([]byte, 4)
+	binary.BigEndian.PutUint32(parentFP, k.ParentFingerprint())
+
+	copied := hdkeychain.NewExtendedKey(
+		k.Version(),
+		b[:privateKeyLen:privateKeyLen],
+		b[privateKeyLen:extendedKeyLen:extendedKeyLen],
+		parentFP,
+		k.Depth(),
+		k.ChildIndex(),
+		true,
+	)
+
+	if _, err := copied.ECPubKey(); err != nil {
+		return nil, errors.Wrap(err, "error computing extended public key")
+	}
+
+	return copied, nil
+}
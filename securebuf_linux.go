@@ -0,0 +1,164 @@
+//go:build linux
+
+package hdwallet
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// secureMinSlotSize is the smallest slot small buffers are allocated in,
+// which is also their alignment.
+const secureMinSlotSize = 32
+
+var (
+	securePageSize = unix.Getpagesize()
+
+	// secureMlock locks memory into RAM, and is replaced by tests to simulate failures.
+	secureMlock = unix.Mlock
+
+	securePool = newSecureSlotPool()
+)
+
+// secureSlotClass identifies slots of the same size,
+// whose pages were either successfully locked into RAM or not.
+type secureSlotClass struct {
+	size   int
+	locked bool
+}
+
+// secureSlotPool keeps the free slots of the pages small buffers are allocated from.
+// Pages are never unmapped, so they're reused by later buffers.
+type secureSlotPool struct {
+	mu   sync.Mutex
+	free map[secureSlotClass][][]byte
+}
+
+func newSecureSlotPool() *secureSlotPool {
+	return &secureSlotPool{free: make(map[secureSlotClass][][]byte)}
+}
+
+// secureAlloc returns a buffer of size zeroed bytes which is locked into RAM (mlock),
+// excluded from core dumps (MADV_DONTDUMP) and surrounded by inaccessible guard pages.
+// Buffers of up to a page share pages with each other, so guard pages only
+// catch overflows out of the page rather than into neighboring buffers.
+// If locking fails, for example because RLIMIT_MEMLOCK was reached, the buffer
+// is kept in memory which isn't locked unless requireLock is set,
+// in which case ErrMemoryNotLocked is returned.
+func secureAlloc(size int, requireLock bool) (*secureBuffer, error) {
+	if size <= 0 {
+		return nil, errors.Errorf("invalid secure buffer size %d", size)
+	}
+
+	if size > securePageSize {
+		return secureAllocPages(size, requireLock)
+	}
+
+	slotSize := secureMinSlotSize
+	for slotSize < size {
+		slotSize <<= 1
+	}
+
+	return securePool.take(slotSize, size, requireLock)
+}
+
+// take returns a slot of slotSize bytes truncated to size, preferring locked slots,
+// and mapping a new page if there's no free slot.
+func (p *secureSlotPool) take(slotSize, size int, requireLock bool) (*secureBuffer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	class := secureSlotClass{size: slotSize, locked: true}
+
+	if len(p.free[class]) == 0 && !requireLock && len(p.free[secureSlotClass{size: slotSize}]) > 0 {
+		class.locked = false
+	}
+
+	if len(p.free[class]) == 0 {
+		page, _, locked, err := mapSecurePages(1, requireLock)
+		if err != nil {
+			return nil, err
+		}
+
+		class.locked = locked
+
+		for off := 0; off+slotSize <= len(page); off += slotSize {
+			p.free[class] = append(p.free[class], page[off:off+slotSize:off+slotSize])
+		}
+	}
+
+	free := p.free[class]
+	slot := free[len(free)-1]
+	p.free[class] = free[:len(free)-1]
+
+	release := func() {
+		wipeBytes(slot)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.free[class] = append(p.free[class], slot)
+	}
+
+	return &secureBuffer{b: slot[:size:size], free: release, locked: class.locked}, nil
+}
+
+// secureAllocPages allocates a buffer larger than a page in its own mapping.
+func secureAllocPages(size int, requireLock bool) (*secureBuffer, error) {
+	data, mapping, locked, err := mapSecurePages((size+securePageSize-1)/securePageSize, requireLock)
+	if err != nil {
+		return nil, err
+	}
+
+	release := func() {
+		wipeBytes(data)
+		_ = unix.Munmap(mapping)
+	}
+
+	return &secureBuffer{b: data[:size:size], free: release, locked: locked}, nil
+}
+
+// mapSecurePages maps n pages excluded from core dumps between two inaccessible guard pages,
+// and locks them into RAM. If locking fails, the pages are returned unlocked,
+// unless requireLock is set in which case ErrMemoryNotLocked is returned.
+// It returns the usable pages along with the whole mapping.
+func mapSecurePages(n int, requireLock bool) (data, mapping []byte, locked bool, err error) {
+	mapping, err = unix.Mmap(-1, 0, (n+2)*securePageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err, "error mapping memory")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = unix.Munmap(mapping)
+		}
+	}()
+
+	end := (n + 1) * securePageSize
+	data = mapping[securePageSize:end:end]
+
+	if err = unix.Mprotect(mapping[:securePageSize], unix.PROT_NONE); err != nil {
+		return nil, nil, false, errors.Wrap(err, "error protecting guard page")
+	}
+
+	if err = unix.Mprotect(mapping[end:], unix.PROT_NONE); err != nil {
+		return nil, nil, false, errors.Wrap(err, "error protecting guard page")
+	}
+
+	if err = unix.Madvise(data, unix.MADV_DONTDUMP); err != nil {
+		return nil, nil, false, errors.Wrap(err, "error excluding memory from core dumps")
+	}
+
+	if mlockErr := secureMlock(data); mlockErr != nil {
+		if requireLock {
+			err = errors.Wrapf(ErrMemoryNotLocked, "%v (see RLIMIT_MEMLOCK)", mlockErr)
+			return nil, nil, false, err
+		}
+
+		return data, mapping, false, nil
+	}
+
+	return data, mapping, true, nil
+}
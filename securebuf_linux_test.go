@@ -0,0 +1,154 @@
+//go:build linux
+
+package hdwallet
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// smapsEntry is a mapping listed in /proc/self/smaps.
+type smapsEntry struct {
+	start, end uintptr
+	perms      string
+	flags      []string
+}
+
+func readSmaps(t *testing.T) []smapsEntry {
+	t.Helper()
+
+	f, err := os.Open("/proc/self/smaps")
+	if err != nil {
+		t.Skipf("smaps unavailable: %v", err)
+	}
+
+	defer f.Close()
+
+	var entries []smapsEntry
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		switch {
+		case len(fields) >= 2 && strings.Contains(fields[0], "-") && !strings.HasSuffix(fields[0], ":"):
+			var entry smapsEntry
+
+			_, err := fmt.Sscanf(fields[0], "%x-%x", &entry.start, &entry.end)
+			require.NoError(t, err)
+
+			entry.perms = fields[1]
+			entries = append(entries, entry)
+		case len(fields) > 0 && fields[0] == "VmFlags:" && len(entries) > 0:
+			entries[len(entries)-1].flags = fields[1:]
+		}
+	}
+
+	require.NoError(t, scanner.Err())
+
+	return entries
+}
+
+func findSmapsEntry(entries []smapsEntry, addr uintptr) (smapsEntry, bool) {
+	for _, entry := range entries {
+		if entry.start <= addr && addr < entry.end {
+			return entry, true
+		}
+	}
+
+	return smapsEntry{}, false
+}
+
+func TestSecureAlloc_Linux(t *testing.T) {
+	for _, size := range []int{32, 3 * securePageSize} {
+		buf, err := newSecureBuffer(size, true)
+		require.NoError(t, err)
+
+		t.Cleanup(buf.destroy)
+
+		assert.True(t, buf.locked)
+
+		entries := readSmaps(t)
+
+		addr := uintptr(unsafe.Pointer(&buf.bytes()[0]))
+		pageStart := addr &^ uintptr(securePageSize-1)
+
+		entry, ok := findSmapsEntry(entries, addr)
+		require.True(t, ok)
+
+		assert.Equal(t, "rw-p", entry.perms)
+		assert.Contains(t, entry.flags, "lo", "memory is locked")
+		assert.Contains(t, entry.flags, "dd", "memory is excluded from core dumps")
+
+		before, ok := findSmapsEntry(entries, pageStart-1)
+		require.True(t, ok)
+		assert.Equal(t, "---p", before.perms, "guard page before the buffer")
+
+		after, ok := findSmapsEntry(entries, entry.end)
+		require.True(t, ok)
+		assert.Equal(t, "---p", after.perms, "guard page after the buffer")
+	}
+}
+
+// failMlock makes locking memory fail until the test finishes,
+// using a new pool so slots locked by other tests aren't reused.
+func failMlock(t *testing.T) {
+	t.Helper()
+
+	mlock, pool := secureMlock, securePool
+	t.Cleanup(func() { secureMlock, securePool = mlock, pool })
+
+	secureMlock = func([]byte) error { return unix.ENOMEM }
+	securePool = newSecureSlotPool()
+}
+
+func TestSecureAlloc_MlockFailure(t *testing.T) {
+	failMlock(t)
+
+	for _, size := range []int{32, 3 * securePageSize} {
+		_, err := newSecureBuffer(size, true)
+		assert.ErrorIs(t, err, ErrMemoryNotLocked)
+
+		buf, err := newSecureBuffer(size, false)
+		require.NoError(t, err)
+
+		t.Cleanup(buf.destroy)
+
+		assert.False(t, buf.locked)
+
+		entry, ok := findSmapsEntry(readSmaps(t), uintptr(unsafe.Pointer(&buf.bytes()[0])))
+		require.True(t, ok)
+
+		assert.NotContains(t, entry.flags, "lo", "memory isn't locked")
+		assert.Contains(t, entry.flags, "dd", "memory is still excluded from core dumps")
+	}
+}
+
+func TestNewHDWallet_MlockFailure(t *testing.T) {
+	wallet, err := NewHDWallet(WithMnemonic(testSecureMnemonic))
+	require.NoError(t, err)
+	assert.True(t, wallet.MemoryLocked())
+
+	failMlock(t)
+
+	wallet, err = NewHDWallet(WithMnemonic(testSecureMnemonic))
+	require.NoError(t, err)
+	assert.False(t, wallet.MemoryLocked())
+
+	addr, err := wallet.DeriveAddressFromIndex(1)
+	require.NoError(t, err)
+
+	_, err = addr.PrivateKey()
+	assert.NoError(t, err)
+
+	_, err = NewHDWallet(WithMnemonic(testSecureMnemonic), WithStrictMemoryLock())
+	assert.ErrorIs(t, err, ErrMemoryNotLocked)
+}
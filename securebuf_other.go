@@ -0,0 +1,29 @@
+//go:build !linux
+
+package hdwallet
+
+import (
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// secureAlloc returns a buffer of size zeroed bytes, which is wiped when destroyed.
+// Locking memory and excluding it from core dumps is only implemented on Linux,
+// so elsewhere the memory is allocated by the Go runtime like any other,
+// or ErrMemoryNotLocked is returned if requireLock is set.
+func secureAlloc(size int, requireLock bool) (*secureBuffer, error) {
+	if size <= 0 {
+		return nil, errors.Errorf("invalid secure buffer size %d", size)
+	}
+
+	if requireLock {
+		return nil, errors.Wrap(ErrMemoryNotLocked, "memory locking is only supported on linux")
+	}
+
+	// allocate words rather than bytes so private scalars can be stored in the buffer.
+	words := make([]uint64, (size+7)/8)
+	b := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+
+	return &secureBuffer{b: b[:size:size], free: func() { wipeBytes(b) }}, nil
+}
@@ -0,0 +1,164 @@
+package hdwallet
+
+import (
+	"math/big"
+	"testing"
+	"unsafe"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecureMnemonic string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestSecureBuffer(t *testing.T) {
+	for _, size := range []int{1, 31, 32, 33, 64, 200, 4096, 10000} {
+		buf, err := newSecureBuffer(size, false)
+		require.NoError(t, err)
+
+		b := buf.bytes()
+
+		assert.Len(t, b, size)
+		assert.Equal(t, size, cap(b), "appending must not spill into other buffers")
+		assert.Equal(t, make([]byte, size), b)
+
+		for i := range b {
+			b[i] = 0xff
+		}
+
+		buf.wipe()
+		assert.Equal(t, make([]byte, size), b)
+
+		buf.destroy()
+		buf.destroy()
+		assert.Nil(t, buf.bytes())
+	}
+
+	_, err := newSecureBuffer(0, false)
+	assert.Error(t, err)
+
+	v := new(vault)
+
+	buf, err := v.newBufferFrom(nil)
+	require.NoError(t, err)
+	assert.Nil(t, buf)
+	assert.Nil(t, buf.bytes())
+
+	buf, err = v.newBufferFrom([]byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), buf.bytes())
+}
+
+// usesBuffer reports whether d's words are stored in buf.
+func usesBuffer(d *big.Int, buf *secureBuffer) bool {
+	words := d.Bits()[:1]
+	return unsafe.Pointer(&words[0]) == unsafe.Pointer(&buf.bytes()[0])
+}
+
+func TestMoveToSecureBuffer(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	want := new(big.Int).Set(key.D)
+
+	buf, err := newSecureBuffer(privateKeyLen, false)
+	require.NoError(t, err)
+
+	moveToSecureBuffer(key, buf)
+
+	assert.Equal(t, 0, want.Cmp(key.D))
+	assert.True(t, usesBuffer(key.D, buf))
+
+	hash := crypto.Keccak256([]byte("secure"))
+
+	sig, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+
+	pubKey, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(*pubKey))
+
+	wipeBigInt(key)
+	assert.Equal(t, make([]byte, privateKeyLen), buf.bytes())
+
+	key.D.SetBytes(want.Bytes())
+	assert.Equal(t, 0, want.Cmp(key.D))
+	assert.True(t, usesBuffer(key.D, buf), "setting the key again reuses the buffer")
+}
+
+func TestVault_SecureMemory(t *testing.T) {
+	w, err := NewHDWallet(WithMnemonic(testSecureMnemonic), WithLockPassword("password"))
+	require.NoError(t, err)
+	require.NoError(t, w.Unlock("password", 0))
+
+	addr, err := w.DeriveAddress()
+	require.NoError(t, err)
+
+	buf, ok := w.vault.keys[addr.privateKey]
+	require.True(t, ok)
+	assert.True(t, usesBuffer(addr.privateKey.D, buf))
+
+	assert.Equal(t, testSecureMnemonic, string(w.vault.mnemonic.bytes()))
+
+	// secrets handed out are copies, which outlive locking the wallet
+	seed := w.Seed()
+	masterKey := w.MasterKey()
+	wantMasterKey := masterKey.String()
+
+	require.NoError(t, w.Lock())
+
+	assert.Equal(t, make([]byte, len(seed)), w.vault.seed.bytes())
+	assert.Equal(t, make([]byte, extendedKeyLen), w.vault.masterBuf.bytes())
+	assert.Equal(t, make([]byte, privateKeyLen), buf.bytes())
+	assert.NotEqual(t, make([]byte, len(seed)), seed)
+	assert.Equal(t, wantMasterKey, masterKey.String())
+
+	require.NoError(t, w.Unlock("password", 0))
+
+	assert.Equal(t, seed, w.Seed())
+	assert.Equal(t, wantMasterKey, w.MasterKey().String())
+	assert.True(t, usesBuffer(addr.privateKey.D, buf))
+}
+
+func TestImportAddress_OtherWallet(t *testing.T) {
+	addr, err := NewAddressFromPrivateKeyHex("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	require.NoError(t, err)
+
+	w1, err := NewHDWallet(WithMnemonic(testSecureMnemonic))
+	require.NoError(t, err)
+
+	w2, err := NewHDWallet(WithMnemonic(testSecureMnemonic))
+	require.NoError(t, err)
+
+	_, err = w1.ImportAddress(addr)
+	require.NoError(t, err)
+
+	_, err = w2.ImportAddress(addr)
+	assert.Error(t, err)
+}
+
+func TestNewHDWallet_WipesTemporaries(t *testing.T) {
+	entropy := []byte{
+		0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f,
+		0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f,
+	}
+	want := append([]byte(nil), entropy...)
+
+	w := newEmptyHDWallet(WithEntropy(entropy))
+	opts := w.opts
+
+	_, err := w.init()
+	require.NoError(t, err)
+
+	assert.Equal(t, make([]byte, len(entropy)), opts.entropy, "the wallet's copy of the entropy is wiped")
+	assert.Equal(t, want, entropy, "the caller's entropy is left untouched")
+	assert.Equal(t, want, w.Entropy())
+}
+
+func TestWipeString(t *testing.T) {
+	s := string([]byte("secret"))
+
+	wipeString(s)
+	assert.Equal(t, string(make([]byte, 6)), s)
+}
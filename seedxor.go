@@ -0,0 +1,113 @@
+package hdwallet
+
+import (
+	"crypto/rand"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// SeedXORMinParts is the minimum number of parts a mnemonic
+// can be split into using SeedXORSplit.
+const SeedXORMinParts int = 2
+
+// SeedXORSplit splits a BIP39 mnemonic into numParts valid BIP39 mnemonics
+// of the same length, compatible with Coldcard's Seed XOR scheme
+// (see https://seedxor.com).
+// The entropies of the returned mnemonics XOR together to the entropy of the
+// original mnemonic, so all parts are required to restore it via SeedXORCombine.
+func SeedXORSplit(mnemonic string, numParts int) ([]string, error) {
+	if numParts < SeedXORMinParts {
+		return nil, errors.Errorf("seed xor requires at least %d parts, got %d", SeedXORMinParts, numParts)
+	}
+
+	entropy, err := bip39.EntropyFromMnemonic(normalizeMnemonic(mnemonic))
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding mnemonic")
+	}
+
+	var (
+		parts    = make([]string, numParts)
+		lastPart = make([]byte, len(entropy))
+	)
+
+	copy(lastPart, entropy)
+
+	for i := 0; i < numParts-1; i++ {
+		partEntropy := make([]byte, len(entropy))
+		if _, err := rand.Read(partEntropy); err != nil {
+			return nil, errors.Wrap(err, "error generating entropy for seed xor part")
+		}
+
+		xorBytes(lastPart, partEntropy)
+
+		parts[i], err = bip39.NewMnemonic(partEntropy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error generating mnemonic for seed xor part %d", i+1)
+		}
+	}
+
+	parts[numParts-1], err = bip39.NewMnemonic(lastPart)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error generating mnemonic for seed xor part %d", numParts)
+	}
+
+	return parts, nil
+}
+
+// SeedXORCombine combines the passed Seed XOR parts back into the
+// original BIP39 mnemonic.
+// Every part must be a valid BIP39 mnemonic (including its checksum),
+// and all parts must be of the same length.
+func SeedXORCombine(parts ...string) (string, error) {
+	entropy, err := seedXOREntropy(parts)
+	if err != nil {
+		return "", err
+	}
+
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", errors.Wrap(err, "error generating combined mnemonic")
+	}
+
+	return mnemonic, nil
+}
+
+func seedXOREntropy(parts []string) ([]byte, error) {
+	if len(parts) < SeedXORMinParts {
+		return nil, errors.Errorf("seed xor requires at least %d parts, got %d", SeedXORMinParts, len(parts))
+	}
+
+	var combined []byte
+
+	for i, part := range parts {
+		partEntropy, err := bip39.EntropyFromMnemonic(normalizeMnemonic(part))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid seed xor part %d", i+1)
+		}
+
+		if combined == nil {
+			combined = partEntropy
+			continue
+		}
+
+		if len(partEntropy) != len(combined) {
+			return nil, errors.Errorf("seed xor part %d has a different word count than part 1", i+1)
+		}
+
+		xorBytes(combined, partEntropy)
+	}
+
+	return combined, nil
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(mnemonic), " ")
+}
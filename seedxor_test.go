@@ -0,0 +1,115 @@
+package hdwallet_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+const (
+	testMnemonicZero   string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testMnemonicOnes   string = "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"
+	testMnemonic7F     string = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	testMnemonic80     string = "letter advice cage absurd amount doctor acoustic avoid letter advice cage above"
+	testMnemonicZero24 string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"
+)
+
+func TestSeedXORCombine(t *testing.T) {
+	tests := []struct {
+		name    string
+		parts   []string
+		want    string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			"zero xor ones",
+			[]string{testMnemonicZero, testMnemonicOnes},
+			testMnemonicOnes,
+			assert.NoError,
+		},
+		{
+			"7f xor ones",
+			[]string{testMnemonic7F, testMnemonicOnes},
+			testMnemonic80,
+			assert.NoError,
+		},
+		{
+			"ones xor ones xor 7f",
+			[]string{testMnemonicOnes, testMnemonicOnes, testMnemonic7F},
+			testMnemonic7F,
+			assert.NoError,
+		},
+		{
+			"single part",
+			[]string{testMnemonic7F},
+			"",
+			assert.Error,
+		},
+		{
+			"mismatched lengths",
+			[]string{testMnemonicZero, testMnemonicZero24},
+			"",
+			assert.Error,
+		},
+		{
+			"bad checksum",
+			[]string{testMnemonicZero, "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo"},
+			"",
+			assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hdwallet.SeedXORCombine(tt.parts...)
+			if !tt.wantErr(t, err, fmt.Sprintf("SeedXORCombine(%v)", tt.parts)) || err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSeedXORSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		numParts int
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{"12 words, 2 parts", testMnemonic7F, 2, assert.NoError},
+		{"12 words, 4 parts", testMnemonic80, 4, assert.NoError},
+		{"24 words, 3 parts", testMnemonicA, 3, assert.NoError},
+		{"1 part", testMnemonicA, 1, assert.Error},
+		{"invalid mnemonic", "hello world", 2, assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := hdwallet.SeedXORSplit(tt.mnemonic, tt.numParts)
+			if !tt.wantErr(t, err, fmt.Sprintf("SeedXORSplit(%v, %v)", tt.mnemonic, tt.numParts)) || err != nil {
+				return
+			}
+
+			assert.Len(t, parts, tt.numParts)
+			for _, part := range parts {
+				assert.True(t, bip39.IsMnemonicValid(part), "part %q is not a valid mnemonic", part)
+			}
+
+			combined, err := hdwallet.SeedXORCombine(parts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mnemonic, combined)
+
+			want, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(tt.mnemonic))
+			assert.NoError(t, err)
+
+			got, err := hdwallet.NewHDWallet(hdwallet.WithSeedXORParts(parts...))
+			assert.NoError(t, err)
+
+			assert.Equal(t, want.Seed(), got.Seed())
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// Signer signs with a secp256k1 key, which doesn't need to live in process memory:
+// HDWalletAddress implements it using its private key, and other implementations
+// can delegate signing to an HSM, a KMS or a remote signer.
+type Signer interface {
+	// Address returns the Ethereum address of the signing key.
+	Address() common.Address
+	// PublicKey returns the signing key's public key.
+	PublicKey() ecdsa.PublicKey
+	// SignHash signs a 32-byte hash, returning a 65-byte [R || S || V] signature
+	// with V = 0 or 1, like crypto.Sign.
+	SignHash(hash []byte) ([]byte, error)
+	// SignTx signs tx for chainID, using the latest signer for the chain.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+var _ Signer = HDWalletAddress{}
+
+// SignHash signs hash with the address' private key (see Signer).
+// It returns ErrLocked while the address' wallet is locked.
+func (a HDWalletAddress) SignHash(hash []byte) ([]byte, error) {
+	var sig []byte
+
+	err := a.withPrivateKey(func(key *ecdsa.PrivateKey) (err error) {
+		sig, err = crypto.Sign(hash, key)
+		return errors.Wrap(err, "error signing hash")
+	})
+
+	return sig, err
+}
+
+// SignTx signs tx for chainID with the address' private key (see Signer).
+// It returns ErrLocked while the address' wallet is locked.
+func (a HDWalletAddress) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+
+	var signed *types.Transaction
+
+	err := a.withPrivateKey(func(key *ecdsa.PrivateKey) (err error) {
+		signed, err = types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+		return errors.Wrap(err, "error signing transaction")
+	})
+
+	return signed, err
+}
+
+// NewTransactOpts returns a *bind.TransactOpts for chainID which signs transactions using signer,
+// like bind.NewKeyedTransactorWithChainID does with a private key.
+func NewTransactOpts(signer Signer, chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+
+	address := signer.Address()
+	chainID = new(big.Int).Set(chainID)
+
+	return &bind.TransactOpts{
+		From: address,
+		Signer: func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if from != address {
+				return nil, bind.ErrNotAuthorized
+			}
+
+			return signer.SignTx(tx, chainID)
+		},
+		Context: context.Background(),
+	}, nil
+}
+
+// SignText signs an EIP-191 personal message (as eth_sign and personal_sign do) using signer,
+// returning a signature with V = 27 or 28 as expected by ecrecover.
+func SignText(signer Signer, text []byte) ([]byte, error) {
+	sig, err := signer.SignHash(accounts.TextHash(text))
+	if err != nil {
+		return nil, err
+	}
+
+	sig[crypto.RecoveryIDOffset] += 27
+
+	return sig, nil
+}
@@ -0,0 +1,188 @@
+package hdwallet_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/signertest"
+)
+
+func testSigners(t *testing.T) map[string]hdwallet.Signer {
+	t.Helper()
+
+	addr, err := hdwallet.NewAddressFromPrivateKeyHex(testPrivateKeyHex)
+	require.NoError(t, err)
+
+	mock, err := signertest.NewRandom()
+	require.NoError(t, err)
+
+	return map[string]hdwallet.Signer{
+		"HDWalletAddress":   addr,
+		"signertest.Signer": mock,
+	}
+}
+
+func TestSigner_SignHash(t *testing.T) {
+	hash := crypto.Keccak256([]byte("hello signer"))
+
+	for name, signer := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			sig, err := signer.SignHash(hash)
+			require.NoError(t, err)
+			require.Len(t, sig, crypto.SignatureLength)
+
+			pubKey, err := crypto.SigToPub(hash, sig)
+			require.NoError(t, err)
+
+			assert.Equal(t, signer.Address(), crypto.PubkeyToAddress(*pubKey))
+			assert.Equal(t, signer.PublicKey(), *pubKey)
+
+			_, err = signer.SignHash([]byte("not a hash"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSigner_SignTx(t *testing.T) {
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	txs := map[string]*types.Transaction{
+		"legacy": types.NewTx(&types.LegacyTx{Nonce: 1, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1e9)}),
+		"dynamic fee": types.NewTx(&types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 2, To: &to, Value: big.NewInt(2),
+			Gas: 21000, GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9),
+		}),
+	}
+
+	for name, signer := range testSigners(t) {
+		for txName, tx := range txs {
+			t.Run(name+" "+txName, func(t *testing.T) {
+				signed, err := signer.SignTx(tx, chainID)
+				require.NoError(t, err)
+
+				sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+				require.NoError(t, err)
+
+				assert.Equal(t, signer.Address(), sender)
+				assert.Equal(t, chainID, signed.ChainId())
+			})
+		}
+	}
+}
+
+func TestNewTransactOpts(t *testing.T) {
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	tx := types.NewTx(&types.LegacyTx{To: &to, Gas: 21000, GasPrice: big.NewInt(1e9)})
+
+	for name, signer := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			_, err := hdwallet.NewTransactOpts(signer, nil)
+			assert.ErrorIs(t, err, bind.ErrNoChainID)
+
+			opts, err := hdwallet.NewTransactOpts(signer, chainID)
+			require.NoError(t, err)
+
+			assert.Equal(t, signer.Address(), opts.From)
+			assert.NotNil(t, opts.Context)
+
+			signed, err := opts.Signer(opts.From, tx)
+			require.NoError(t, err)
+
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+			require.NoError(t, err)
+			assert.Equal(t, signer.Address(), sender)
+
+			_, err = opts.Signer(to, tx)
+			assert.ErrorIs(t, err, bind.ErrNotAuthorized)
+		})
+	}
+
+	t.Run("signer error", func(t *testing.T) {
+		mock, err := signertest.NewRandom()
+		require.NoError(t, err)
+
+		opts, err := hdwallet.NewTransactOpts(mock, chainID)
+		require.NoError(t, err)
+
+		errHSM := errors.New("token removed")
+		mock.SetError(errHSM)
+
+		_, err = opts.Signer(opts.From, tx)
+		assert.ErrorIs(t, err, errHSM)
+		assert.Empty(t, mock.Transactions())
+
+		mock.SetError(nil)
+
+		_, err = opts.Signer(opts.From, tx)
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.Transaction{tx}, mock.Transactions())
+	})
+}
+
+func TestHDWalletAddress_TransactOptsForChainID(t *testing.T) {
+	addr, err := hdwallet.NewAddressFromPrivateKeyHex(testPrivateKeyHex)
+	require.NoError(t, err)
+
+	_, err = addr.TransactOptsForChainID(nil)
+	assert.ErrorIs(t, err, bind.ErrNoChainID)
+
+	opts, err := addr.TransactOptsForChainID(big.NewInt(1))
+	require.NoError(t, err)
+
+	assert.Equal(t, common.HexToAddress(testPrivateKeyAddr), opts.From)
+
+	again, err := addr.TransactOptsForChainID(big.NewInt(1))
+	require.NoError(t, err)
+	assert.Same(t, opts, again)
+
+	other, err := addr.TransactOptsForChainID(big.NewInt(5))
+	require.NoError(t, err)
+	assert.NotSame(t, opts, other)
+
+	// chain IDs which don't fit in a uint64 aren't truncated
+	large, err := addr.TransactOptsForChainID(new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)))
+	require.NoError(t, err)
+	assert.NotSame(t, opts, large)
+}
+
+func TestSignText(t *testing.T) {
+	text := []byte("hello world")
+
+	for name, signer := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			sig, err := hdwallet.SignText(signer, text)
+			require.NoError(t, err)
+
+			assert.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset])
+
+			sig[crypto.RecoveryIDOffset] -= 27
+
+			pubKey, err := crypto.SigToPub(accounts.TextHash(text), sig)
+			require.NoError(t, err)
+			assert.Equal(t, signer.Address(), crypto.PubkeyToAddress(*pubKey))
+		})
+	}
+
+	t.Run("signer error", func(t *testing.T) {
+		mock, err := signertest.NewRandom()
+		require.NoError(t, err)
+
+		mock.SetError(errors.New("unavailable"))
+
+		_, err = hdwallet.SignText(mock, text)
+		assert.Error(t, err)
+		assert.Empty(t, mock.Hashes())
+	})
+}
@@ -0,0 +1,102 @@
+// Package signertest provides an in-memory hdwallet.Signer for tests,
+// which records what it signs and can be made to fail.
+package signertest
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+)
+
+var _ hdwallet.Signer = (*Signer)(nil)
+
+// Signer is an hdwallet.Signer backed by a private key held in memory.
+// It is safe for concurrent use.
+type Signer struct {
+	key *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	err    error
+	hashes [][]byte
+	txs    []*types.Transaction
+}
+
+// New returns a Signer using key.
+func New(key *ecdsa.PrivateKey) *Signer {
+	return &Signer{key: key}
+}
+
+// NewRandom returns a Signer using a newly generated key.
+func NewRandom() (*Signer, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating key")
+	}
+
+	return New(key), nil
+}
+
+func (s *Signer) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *Signer) PublicKey() ecdsa.PublicKey {
+	return s.key.PublicKey
+}
+
+func (s *Signer) SignHash(hash []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	s.hashes = append(s.hashes, common.CopyBytes(hash))
+
+	return crypto.Sign(hash, s.key)
+}
+
+func (s *Signer) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	s.txs = append(s.txs, tx)
+
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// SetError makes every subsequent signing call fail with err, until reset using SetError(nil).
+func (s *Signer) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Hashes returns the hashes signed so far.
+func (s *Signer) Hashes() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]byte(nil), s.hashes...)
+}
+
+// Transactions returns the unsigned transactions signed so far.
+func (s *Signer) Transactions() []*types.Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*types.Transaction(nil), s.txs...)
+}
@@ -0,0 +1,92 @@
+package hdwallet
+
+import (
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+)
+
+// slip132Versions holds the public and private extended key version bytes
+// registered in SLIP-132 (see https://github.com/satoshilabs/slips/blob/master/slip-0132.md).
+type slip132Versions struct {
+	public  [4]byte
+	private [4]byte
+}
+
+var (
+	slip132MainNet = map[BitcoinAddressType]slip132Versions{
+		BitcoinP2SHP2WPKH: {
+			public:  [4]byte{0x04, 0x9d, 0x7c, 0xb2}, // ypub
+			private: [4]byte{0x04, 0x9d, 0x78, 0x78}, // yprv
+		},
+		BitcoinP2WPKH: {
+			public:  [4]byte{0x04, 0xb2, 0x47, 0x46}, // zpub
+			private: [4]byte{0x04, 0xb2, 0x43, 0x0c}, // zprv
+		},
+	}
+	slip132TestNet = map[BitcoinAddressType]slip132Versions{
+		BitcoinP2SHP2WPKH: {
+			public:  [4]byte{0x04, 0x4a, 0x52, 0x62}, // upub
+			private: [4]byte{0x04, 0x4a, 0x4e, 0x28}, // uprv
+		},
+		BitcoinP2WPKH: {
+			public:  [4]byte{0x04, 0x5f, 0x1c, 0xf6}, // vpub
+			private: [4]byte{0x04, 0x5f, 0x18, 0xbc}, // vprv
+		},
+	}
+)
+
+// slip132Version returns the extended key version bytes for addrType on the network described by netParams.
+// P2PKH and P2TR keys use the network's own version bytes (xpub/tpub);
+// segwit keys use SLIP-132 version bytes, with regtest and signet
+// sharing testnet's version bytes.
+func slip132Version(addrType BitcoinAddressType, netParams *chaincfg.Params, private bool) ([]byte, error) {
+	var versions map[BitcoinAddressType]slip132Versions
+
+	switch netParams.HDPublicKeyID {
+	case chaincfg.MainNetParams.HDPublicKeyID:
+		versions = slip132MainNet
+	case chaincfg.TestNet3Params.HDPublicKeyID:
+		versions = slip132TestNet
+	}
+
+	v, ok := versions[addrType]
+	if !ok {
+		if addrType == BitcoinP2SHP2WPKH || addrType == BitcoinP2WPKH {
+			return nil, errors.Errorf("no SLIP-132 version bytes registered for %s keys on network %s", addrType, netParams.Name)
+		}
+
+		if private {
+			return netParams.HDPrivateKeyID[:], nil
+		}
+
+		return netParams.HDPublicKeyID[:], nil
+	}
+
+	if private {
+		return v.private[:], nil
+	}
+
+	return v.public[:], nil
+}
+
+func serializeSLIP132(key *hdkeychain.ExtendedKey, addrType BitcoinAddressType, netParams *chaincfg.Params, private bool) (string, error) {
+	version, err := slip132Version(addrType, netParams, private)
+	if err != nil {
+		return "", err
+	}
+
+	if !private {
+		key, err = key.Neuter()
+		if err != nil {
+			return "", errors.Wrap(err, "error neutering extended key")
+		}
+	}
+
+	key, err = key.CloneWithVersion(version)
+	if err != nil {
+		return "", errors.Wrap(err, "error setting extended key version")
+	}
+
+	return key.String(), nil
+}
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"encoding/hex"
-	"fmt"
+	"math"
 	"math/rand"
 	"strings"
 	"time"
@@ -21,6 +21,8 @@ type newBIP39Data struct {
 	Mnemonic string
 	Entropy  []byte
 	Seed     []byte
+
+	generatedMnemonic bool // Mnemonic was generated rather than passed using WithMnemonic
 }
 
 type rawDerived struct {
@@ -29,8 +31,6 @@ type rawDerived struct {
 	PubKey  ecdsa.PublicKey
 }
 
-const baseDerivationPath string = "m/44'/60'/%[1]d'/0/"
-
 func isHardenedIdx(idx int) bool {
 	return idx >= hdkeychain.HardenedKeyStart
 }
@@ -39,22 +39,20 @@ func getHardenedIdx(idx int) int {
 	return idx - hdkeychain.HardenedKeyStart
 }
 
-func addressDerivationPathFromIdx(accountIdx, addressIdx int) accounts.DerivationPath {
-	var suffix string
-
-	if isHardenedIdx(addressIdx) {
-		addressIdx = getHardenedIdx(addressIdx)
-		suffix = "'"
+func addressDerivationPathFromIdx(coinType CoinType, accountIdx, addressIdx int) (accounts.DerivationPath, error) {
+	if isHardenedIdx(int(coinType)) {
+		return nil, errors.Errorf("invalid coin type %d", uint32(coinType))
 	}
 
-	newPath := fmt.Sprintf(baseDerivationPath, accountIdx) + fmt.Sprintf("%[1]d%[2]s", addressIdx, suffix)
+	if accountIdx < 0 || isHardenedIdx(accountIdx) {
+		return nil, errors.Errorf("invalid account index %d", accountIdx)
+	}
 
-	p, err := accounts.ParseDerivationPath(newPath)
-	if err != nil {
-		panic(err)
+	if addressIdx < 0 || uint64(addressIdx) > math.MaxUint32 {
+		return nil, errors.Errorf("invalid address index %d", addressIdx)
 	}
 
-	return p
+	return bip44Path(coinType, accountIdx, addressIdx), nil
 }
 
 func makeBIP39DataFromMnemonic(entropy []byte, mnemonic, passphrase string) (*newBIP39Data, error) {
@@ -71,13 +69,16 @@ func makeBIP39DataFromMnemonic(entropy []byte, mnemonic, passphrase string) (*ne
 }
 
 // EntropyFromString returns the []byte representation
-// of a hex-encoded entropy string.
-func EntropyFromString(entropy string) []byte {
-	entropy = strings.TrimPrefix(entropy, "0x")
+// of a hex-encoded entropy string, which may be prefixed with "0x".
+func EntropyFromString(entropy string) ([]byte, error) {
+	entropy = strings.TrimPrefix(strings.TrimPrefix(entropy, "0x"), "0X")
 
-	e, _ := hex.DecodeString(entropy)
+	e, err := hex.DecodeString(entropy)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid hex-encoded entropy")
+	}
 
-	return e
+	return e, nil
 }
 
 func makeBIP39Data(opts *walletOpts) (*newBIP39Data, error) {
@@ -89,61 +90,102 @@ func makeBIP39Data(opts *walletOpts) (*newBIP39Data, error) {
 		mnemonic string
 	)
 
+	if opts.seed != nil {
+		return &newBIP39Data{Seed: opts.seed}, nil
+	}
+
+	if len(opts.seedXORParts) > 0 {
+		opts.entropy, err = seedXOREntropy(opts.seedXORParts)
+		if err != nil {
+			return nil, errors.Wrap(err, "error combining seed xor parts")
+		}
+	}
+
 	if opts.entropy != nil {
 		mnemonic, err = bip39.NewMnemonic(opts.entropy)
 		if err != nil {
 			return nil, err
 		}
 
-		return makeBIP39DataFromMnemonic(opts.entropy, mnemonic, opts.passphrase)
-	} else {
-		entropy, err = bip39.NewEntropy(opts.entropyBits)
-		if err != nil {
-			return nil, errors.Wrap(err, "error generating entropy")
-		}
+		return makeBIP39DataFromGeneratedMnemonic(opts.entropy, mnemonic, opts.passphrase)
 	}
 
 	if opts.mnemonic != "" {
-		mnemonic = opts.mnemonic
-	} else {
-		mnemonic, err = bip39.NewMnemonic(entropy)
+		// keep the wallet's entropy consistent with the passed mnemonic
+		// rather than generating unrelated random entropy.
+		entropy, err = bip39.EntropyFromMnemonic(opts.mnemonic)
 		if err != nil {
-			return nil, errors.Wrap(err, "error generating mnemonic")
+			return nil, errors.Wrap(err, "invalid mnemonic")
 		}
+
+		return makeBIP39DataFromMnemonic(entropy, opts.mnemonic, opts.passphrase)
+	}
+
+	entropy, err = bip39.NewEntropy(opts.entropyBits)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating entropy")
 	}
 
-	return makeBIP39DataFromMnemonic(entropy, mnemonic, opts.passphrase)
+	mnemonic, err = bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating mnemonic")
+	}
+
+	return makeBIP39DataFromGeneratedMnemonic(entropy, mnemonic, opts.passphrase)
 }
 
-func deriveNewAdressFromAccountKey(accountKey *hdkeychain.ExtendedKey, accountIdx, addressIdx int) (*rawDerived, error) {
-	var (
-		derivedKey = accountKey
-		err        error
-	)
+func makeBIP39DataFromGeneratedMnemonic(entropy []byte, mnemonic, passphrase string) (*newBIP39Data, error) {
+	data, err := makeBIP39DataFromMnemonic(entropy, mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
 
-	path := addressDerivationPathFromIdx(accountIdx, addressIdx)
+	data.generatedMnemonic = true
 
-	for _, n := range path {
-		if derivedKey.IsAffectedByIssue172() {
-			derivedKey, err = derivedKey.Derive(n)
-		} else {
-			derivedKey, err = derivedKey.DeriveNonStandard(n)
-		}
+	return data, nil
+}
 
-		if err != nil {
-			return nil, err
-		}
+// wipeBIP39Data wipes the secrets a wallet was created from once its vault holds copies of them.
+// Mnemonics passed using WithMnemonic belong to the caller and are left untouched.
+func wipeBIP39Data(data *newBIP39Data, opts *walletOpts) {
+	wipeBytes(opts.seed)
+	wipeBytes(opts.entropy)
+
+	if data == nil {
+		return
 	}
 
+	wipeBytes(data.Seed)
+	wipeBytes(data.Entropy)
+
+	if data.generatedMnemonic {
+		wipeString(data.Mnemonic)
+	}
+}
+
+func deriveNewAddressFromChainKey(chainKey *hdkeychain.ExtendedKey, addressIdx int, legacy bool) (*rawDerived, error) {
+	if addressIdx < 0 || uint64(addressIdx) > math.MaxUint32 {
+		return nil, errors.Errorf("invalid address index %d", addressIdx)
+	}
+
+	derivedKey, err := deriveExtendedKey(chainKey, accounts.DerivationPath{uint32(addressIdx)}, legacy)
 	if err != nil {
-		return nil, errors.Wrap(err, "error creating child Extended Key")
+		return nil, err
 	}
 
-	privKeyRaw, err := derivedKey.ECPrivKey()
+	defer derivedKey.Zero()
+
+	return rawDerivedFromExtendedKey(derivedKey)
+}
+
+func rawDerivedFromExtendedKey(key *hdkeychain.ExtendedKey) (*rawDerived, error) {
+	privKeyRaw, err := key.ECPrivKey()
 	if err != nil {
 		return nil, err
 	}
 
+	defer privKeyRaw.Zero()
+
 	privKey := privKeyRaw.ToECDSA()
 	pubKey := privKey.Public().(*ecdsa.PublicKey)
 
@@ -154,6 +196,61 @@ func deriveNewAdressFromAccountKey(accountKey *hdkeychain.ExtendedKey, accountId
 	}, nil
 }
 
+// childAddress returns the address of parent's child at childIdx,
+// zeroing the child key and its private key once done.
+func childAddress(parent *hdkeychain.ExtendedKey, childIdx uint32) (common.Address, error) {
+	key, err := parent.Derive(childIdx)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "error creating child Extended Key")
+	}
+
+	defer key.Zero()
+
+	derived, err := rawDerivedFromExtendedKey(key)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	defer wipeBigInt(derived.PrivKey)
+
+	return derived.Address, nil
+}
+
+// deriveExtendedKey walks path starting at key using standard BIP32 derivation.
+// If legacy is true, keys are instead derived the way btcutil did prior to fixing
+// https://github.com/btcsuite/btcutil/issues/172, which drops the leading zero bytes
+// of private keys before hardened derivation. This is only ever needed to
+// restore wallets created using that derivation.
+// Intermediate keys are zeroed, while key itself is left untouched.
+func deriveExtendedKey(key *hdkeychain.ExtendedKey, path accounts.DerivationPath, legacy bool) (*hdkeychain.ExtendedKey, error) {
+	parent := key
+
+	for _, n := range path {
+		var (
+			child *hdkeychain.ExtendedKey
+			err   error
+		)
+
+		if legacy {
+			child, err = parent.DeriveNonStandard(n)
+		} else {
+			child, err = parent.Derive(n)
+		}
+
+		if parent != key {
+			parent.Zero()
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating child Extended Key")
+		}
+
+		parent = child
+	}
+
+	return parent, nil
+}
+
 func addressEq(a, b common.Address) bool {
 	return bytes.Equal(
 		a.Bytes(),
@@ -0,0 +1,30 @@
+package hdwallet
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+)
+
+func FuzzAddressDerivationPathFromIdx(f *testing.F) {
+	f.Add(uint32(60), 0, 0)
+	f.Add(uint32(0), 1, 1<<31)
+	f.Add(uint32(1<<31), -1, -1)
+	f.Add(uint32(60), 1<<31, 1<<32)
+
+	f.Fuzz(func(t *testing.T, coinType uint32, accountIdx, addressIdx int) {
+		path, err := addressDerivationPathFromIdx(CoinType(coinType), accountIdx, addressIdx)
+		if err != nil {
+			return
+		}
+
+		parsed, err := accounts.ParseDerivationPath(path.String())
+		if err != nil {
+			t.Fatalf("derivation path %s does not parse: %v", path, err)
+		}
+
+		if parsed.String() != path.String() {
+			t.Fatalf("derivation path %s parsed as %s", path, parsed)
+		}
+	})
+}
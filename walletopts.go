@@ -1,11 +1,25 @@
 package hdwallet
 
+import (
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+)
+
 type walletOpts struct {
 	passphrase       string
 	entropyBits      int
 	mnemonic         string
 	entropy          []byte
+	seed             []byte
+	seedXORParts     []string
+	masterKey        *hdkeychain.ExtendedKey
+	lockPassword     *string
 	newKeyForAccount bool
+	netParams        *chaincfg.Params
+	coinType         CoinType
+	legacyDerivation bool
+	strictMemoryLock bool
 }
 
 type funcWalletOpt struct {
@@ -36,15 +50,101 @@ func WithEntropyBits(entropyBits int) NewWalletOpt {
 	})
 }
 
+// WithMnemonic constructs the wallet from a BIP39 mnemonic.
+// The wallet's Entropy is the mnemonic's entropy, rather than unrelated random entropy
+// as in earlier versions.
 func WithMnemonic(mnemonic string) NewWalletOpt {
 	return newFuncWalletOpt(func(opts *walletOpts) {
 		opts.mnemonic = mnemonic
 	})
 }
 
+// WithEntropy constructs the wallet from BIP39 entropy.
+// The entropy is copied, so the caller remains responsible for wiping it.
 func WithEntropy(entropy []byte) NewWalletOpt {
 	return newFuncWalletOpt(func(opts *walletOpts) {
-		opts.entropy = entropy
+		opts.entropy = common.CopyBytes(entropy)
+	})
+}
+
+// WithSeed constructs the wallet directly from a raw BIP32 seed
+// (16 to 64 bytes) instead of a BIP39 mnemonic, in which case
+// Mnemonic() and Entropy() are empty.
+// Takes precedence over all other options which set the wallet's seed.
+// The seed is copied, so the caller remains responsible for wiping it.
+func WithSeed(seed []byte) NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.seed = common.CopyBytes(seed)
+	})
+}
+
+// WithMasterKey constructs the wallet directly from an extended private key at depth 0
+// (for example an xprv parsed using ParseExtendedKey) instead of a BIP39 mnemonic,
+// in which case Mnemonic(), Entropy() and Seed() are empty.
+// Takes precedence over all other options which set the wallet's seed.
+func WithMasterKey(masterKey *hdkeychain.ExtendedKey) NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.masterKey = masterKey
+	})
+}
+
+// WithLockPassword keeps the wallet's secrets (its mnemonic, seed, master key and
+// every private key) encrypted in memory using password, and creates the wallet locked:
+// HDWallet.Unlock must be called before signing or deriving new addresses.
+func WithLockPassword(password string) NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.lockPassword = &password
+	})
+}
+
+// WithStrictMemoryLock makes creating the wallet, deriving addresses and importing
+// private keys fail with ErrMemoryNotLocked when their secrets can't be locked into RAM,
+// for example because RLIMIT_MEMLOCK was reached or on platforms other than Linux.
+// By default, secrets are then kept in memory which isn't locked,
+// which HDWallet.MemoryLocked reports.
+func WithStrictMemoryLock() NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.strictMemoryLock = true
+	})
+}
+
+// WithSeedXORParts constructs the wallet from the entropy obtained by
+// combining the passed Coldcard-compatible Seed XOR parts (see SeedXORCombine).
+// Takes precedence over WithMnemonic and WithEntropy.
+func WithSeedXORParts(parts ...string) NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.seedXORParts = parts
+	})
+}
+
+// WithNetwork sets the network whose parameters are used when serializing
+// extended keys and encoding Bitcoin addresses,
+// for example &chaincfg.TestNet3Params, &chaincfg.RegressionNetParams or &chaincfg.SigNetParams.
+// Defaults to &chaincfg.MainNetParams.
+func WithNetwork(netParams *chaincfg.Params) NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.netParams = netParams
+	})
+}
+
+// WithCoinType sets the SLIP-44 coin type used in the wallet's
+// derivation paths (m/44'/coin_type'/account'/0/index).
+// The coin type must be a built-in or registered using RegisterCoinType.
+// Defaults to CoinTypeEthereum.
+func WithCoinType(coinType CoinType) NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.coinType = coinType
+	})
+}
+
+// WithLegacyDerivation derives keys the way btcutil did prior to fixing
+// https://github.com/btcsuite/btcutil/issues/172, where roughly 1 in 256 hardened
+// derivations from a private key with a leading zero byte differ from BIP32.
+// Only use this to restore wallets which were created with that derivation;
+// by default, wallets use standard BIP32 derivation.
+func WithLegacyDerivation(legacy bool) NewWalletOpt {
+	return newFuncWalletOpt(func(opts *walletOpts) {
+		opts.legacyDerivation = legacy
 	})
 }
 
@@ -57,5 +157,7 @@ func WithDeriveKeyForAccount(newKey bool) NewWalletOpt {
 func defaultWalletOpts() *walletOpts {
 	return &walletOpts{
 		entropyBits: Entropy256Bit,
+		netParams:   &chaincfg.MainNetParams,
+		coinType:    CoinTypeEthereum,
 	}
 }
@@ -0,0 +1,209 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// WatchOnlyAddress is an address known only by its public metadata,
+// as imported using ImportAddressesJSON or ImportAddressesCSV.
+type WatchOnlyAddress struct {
+	address         common.Address
+	publicKey       ecdsa.PublicKey
+	derivationPath  accounts.DerivationPath
+	accountIndex    int
+	derivationIndex int
+	hardened        bool
+	imported        bool
+}
+
+// WatchOnlyWallet is a read-only view of addresses exported from an HDWallet,
+// which can't sign anything.
+type WatchOnlyWallet struct {
+	addrs     []*WatchOnlyAddress
+	byAddress map[common.Address]*WatchOnlyAddress
+	byPath    map[string]*WatchOnlyAddress
+}
+
+// NewWatchOnlyWallet validates the passed address records and returns a watch-only view of them.
+// Each record's addresses must match its public key, and its derivation path must
+// match its account, index and hardened flag; imported records must not have a derivation path.
+func NewWatchOnlyWallet(records ...AddressRecord) (*WatchOnlyWallet, error) {
+	w := &WatchOnlyWallet{
+		byAddress: make(map[common.Address]*WatchOnlyAddress, len(records)),
+		byPath:    make(map[string]*WatchOnlyAddress, len(records)),
+	}
+
+	for _, record := range records {
+		addr, err := watchOnlyAddressFromRecord(record)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid record for address %s", record.ChecksumAddress)
+		}
+
+		if addr.imported {
+			if _, ok := w.byAddress[addr.address]; ok {
+				return nil, errors.Errorf("duplicate imported address %s", addr.address)
+			}
+		} else {
+			if _, ok := w.byPath[addr.derivationPath.String()]; ok {
+				return nil, errors.Errorf("duplicate derivation path %s", addr.derivationPath)
+			}
+
+			w.byPath[addr.derivationPath.String()] = addr
+		}
+
+		w.addrs = append(w.addrs, addr)
+
+		if _, ok := w.byAddress[addr.address]; !ok {
+			w.byAddress[addr.address] = addr
+		}
+	}
+
+	return w, nil
+}
+
+func watchOnlyAddressFromRecord(record AddressRecord) (*WatchOnlyAddress, error) {
+	pubKeyBytes := common.FromHex(record.PublicKey)
+	if len(pubKeyBytes) == 64 {
+		pubKeyBytes = append([]byte{0x04}, pubKeyBytes...)
+	}
+
+	pubKey, err := crypto.UnmarshalPubkey(pubKeyBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid public key")
+	}
+
+	address := crypto.PubkeyToAddress(*pubKey)
+
+	if record.Address != "" && !strings.EqualFold(record.Address, address.Hex()) {
+		return nil, errors.Errorf("address %s does not match public key", record.Address)
+	}
+
+	if record.ChecksumAddress != "" && record.ChecksumAddress != address.Hex() {
+		return nil, errors.Errorf("checksum address %s does not match public key", record.ChecksumAddress)
+	}
+
+	if record.Imported {
+		if record.DerivationPath != "" {
+			return nil, errors.New("imported address has a derivation path")
+		}
+
+		return &WatchOnlyAddress{
+			address:   address,
+			publicKey: *pubKey,
+			imported:  true,
+		}, nil
+	}
+
+	if !strings.HasPrefix(record.DerivationPath, "m/") {
+		return nil, errors.Errorf("derivation path %q must be absolute", record.DerivationPath)
+	}
+
+	path, err := accounts.ParseDerivationPath(record.DerivationPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid derivation path %q", record.DerivationPath)
+	}
+
+	if len(path) != 5 {
+		return nil, errors.Errorf("derivation path %s is not a BIP44 address path", path)
+	}
+
+	idx := record.Index
+	if record.Hardened {
+		idx += hdkeychain.HardenedKeyStart
+	}
+
+	wantPath, err := addressDerivationPathFromIdx(CoinType(path[1]-hdkeychain.HardenedKeyStart), record.Account, idx)
+	if err != nil || path.String() != wantPath.String() {
+		return nil, errors.Errorf(
+			"derivation path %s does not match account %d, index %d and hardened %t",
+			path, record.Account, record.Index, record.Hardened,
+		)
+	}
+
+	return &WatchOnlyAddress{
+		address:         address,
+		publicKey:       *pubKey,
+		derivationPath:  path,
+		accountIndex:    record.Account,
+		derivationIndex: record.Index,
+		hardened:        record.Hardened,
+	}, nil
+}
+
+// Addresses returns the wallet's addresses, in the order they were imported.
+func (w *WatchOnlyWallet) Addresses() []*WatchOnlyAddress {
+	addrs := make([]*WatchOnlyAddress, len(w.addrs))
+	copy(addrs, w.addrs)
+
+	return addrs
+}
+
+// FindByAddress returns the imported address matching address.
+func (w *WatchOnlyWallet) FindByAddress(address common.Address) (*WatchOnlyAddress, bool) {
+	addr, ok := w.byAddress[address]
+	return addr, ok
+}
+
+// FindByPath returns the imported address at the passed absolute derivation path.
+func (w *WatchOnlyWallet) FindByPath(path string) (*WatchOnlyAddress, bool) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "m/") {
+		return nil, false
+	}
+
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	addr, ok := w.byPath[derivationPath.String()]
+
+	return addr, ok
+}
+
+func (a WatchOnlyAddress) Address() common.Address {
+	return a.address
+}
+
+func (a WatchOnlyAddress) PublicKey() ecdsa.PublicKey {
+	return a.publicKey
+}
+
+func (a WatchOnlyAddress) PublicKeyHex() string {
+	return common.Bytes2Hex(crypto.FromECDSAPub(&a.publicKey)[1:])
+}
+
+// DerivationPath returns the address' derivation path,
+// or an empty string if the address was imported.
+func (a WatchOnlyAddress) DerivationPath() string {
+	if a.imported {
+		return ""
+	}
+
+	return a.derivationPath.String()
+}
+
+func (a WatchOnlyAddress) AccountIndex() int {
+	return a.accountIndex
+}
+
+func (a WatchOnlyAddress) DerivationIndex() int {
+	return a.derivationIndex
+}
+
+func (a WatchOnlyAddress) Hardened() bool {
+	return a.hardened
+}
+
+// Imported reports whether the address was created from a raw private key
+// rather than derived from an HD wallet's seed.
+func (a WatchOnlyAddress) Imported() bool {
+	return a.imported
+}
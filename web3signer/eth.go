@@ -0,0 +1,114 @@
+package web3signer
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/policy"
+)
+
+// ethAPI implements the eth_ JSON-RPC methods served by Web3Signer.
+type ethAPI struct {
+	wallet  *hdwallet.HDWallet
+	chainID *big.Int
+	policy  *policy.Engine
+}
+
+// Accounts returns the wallet's derived and imported addresses.
+func (a *ethAPI) Accounts() []common.Address {
+	walletAddrs := a.wallet.Accounts()
+
+	addrs := make([]common.Address, len(walletAddrs))
+	for i, addr := range walletAddrs {
+		addrs[i] = addr.Address()
+	}
+
+	return addrs
+}
+
+// Sign signs data as an EIP-191 personal message, using V = 27 or 28.
+func (a *ethAPI) Sign(ctx context.Context, address common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	addr, err := a.account(address)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, msg := accounts.TextAndHash(data)
+
+	policyReq := &policy.Request{From: addr.Address(), Message: []byte(msg)}
+	if err := checkPolicy(ctx, a.policy, policyReq); err != nil {
+		return nil, err
+	}
+
+	sig, err := signHash(addr, hash)
+	policyDone(a.policy, policyReq, err)
+
+	return sig, err
+}
+
+// SignTransaction signs a transaction, returning its RLP encoding.
+// If the transaction has no chain ID, the server's chain ID is used,
+// and transactions for other chains are refused.
+func (a *ethAPI) SignTransaction(ctx context.Context, args apitypes.SendTxArgs) (hexutil.Bytes, error) {
+	addr, err := a.account(args.From.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	if args.ChainID == nil {
+		args.ChainID = (*hexutil.Big)(a.chainID)
+	} else if args.ChainID.ToInt().Cmp(a.chainID) != 0 {
+		return nil, errors.Errorf("chain id %v doesn't match the signer's chain id %v", args.ChainID.ToInt(), a.chainID)
+	}
+
+	if args.MaxFeePerGas == nil && args.GasPrice == nil {
+		return nil, errors.New("gasPrice or maxFeePerGas must be specified")
+	}
+
+	if args.MaxFeePerGas != nil && args.MaxPriorityFeePerGas == nil {
+		return nil, errors.New("maxPriorityFeePerGas must be specified with maxFeePerGas")
+	}
+
+	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
+		return nil, errors.New("data and input must match when both are specified")
+	}
+
+	tx := args.ToTransaction()
+	chainID := (*big.Int)(args.ChainID)
+
+	policyReq := &policy.Request{From: addr.Address(), ChainID: chainID, Tx: tx}
+	if err := checkPolicy(ctx, a.policy, policyReq); err != nil {
+		return nil, err
+	}
+
+	signed, err := addr.SignTx(tx, chainID)
+	policyDone(a.policy, policyReq, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding signed transaction")
+	}
+
+	return raw, nil
+}
+
+func (a *ethAPI) account(address common.Address) (*hdwallet.HDWalletAddress, error) {
+	addr, ok := a.wallet.FindByAddress(address)
+	if !ok {
+		return nil, errors.Errorf("unknown account %s", address)
+	}
+
+	return addr, nil
+}
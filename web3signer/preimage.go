@@ -0,0 +1,134 @@
+package web3signer
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go/policy"
+)
+
+// legacyPreimage is the signing preimage of a legacy transaction (see types.Signer.Hash),
+// which ends with [chainID, 0, 0] if it's replay-protected (EIP-155).
+type legacyPreimage struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	EIP155   []*big.Int `rlp:"tail"`
+}
+
+// accessListPreimage is the signing preimage of an EIP-2930 transaction, after its type.
+type accessListPreimage struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+// dynamicFeePreimage is the signing preimage of an EIP-1559 transaction, after its type.
+type dynamicFeePreimage struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+// decodeUnsignedTx decodes data if it's the signing preimage of a transaction,
+// whose signature is then a valid signature of the transaction, returning the transaction
+// along with the chain ID it's signed for, which is nil if it isn't replay-protected.
+func decodeUnsignedTx(data []byte) (tx *types.Transaction, chainID *big.Int, ok bool) {
+	if len(data) == 0 {
+		return nil, nil, false
+	}
+
+	switch data[0] {
+	case types.AccessListTxType:
+		var p accessListPreimage
+		if rlp.DecodeBytes(data[1:], &p) != nil {
+			return nil, nil, false
+		}
+
+		tx, chainID = types.NewTx(&types.AccessListTx{
+			ChainID: p.ChainID, Nonce: p.Nonce, GasPrice: p.GasPrice, Gas: p.Gas,
+			To: p.To, Value: p.Value, Data: p.Data, AccessList: p.AccessList,
+		}), p.ChainID
+	case types.DynamicFeeTxType:
+		var p dynamicFeePreimage
+		if rlp.DecodeBytes(data[1:], &p) != nil {
+			return nil, nil, false
+		}
+
+		tx, chainID = types.NewTx(&types.DynamicFeeTx{
+			ChainID: p.ChainID, Nonce: p.Nonce, GasTipCap: p.GasTipCap, GasFeeCap: p.GasFeeCap, Gas: p.Gas,
+			To: p.To, Value: p.Value, Data: p.Data, AccessList: p.AccessList,
+		}), p.ChainID
+	default:
+		var p legacyPreimage
+		if rlp.DecodeBytes(data, &p) != nil {
+			return nil, nil, false
+		}
+
+		switch len(p.EIP155) {
+		case 0:
+		case 3:
+			chainID = p.EIP155[0]
+		default:
+			return nil, nil, false
+		}
+
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce: p.Nonce, GasPrice: p.GasPrice, Gas: p.Gas, To: p.To, Value: p.Value, Data: p.Data,
+		})
+	}
+
+	// only data which hashes exactly like the transaction's preimage can be used as its signature.
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.LatestSignerForChainID(chainID)
+	}
+
+	if !bytes.Equal(signer.Hash(tx).Bytes(), crypto.Keccak256(data)) {
+		return nil, nil, false
+	}
+
+	return tx, chainID, true
+}
+
+// rawSignPolicyRequest returns the request checked against engine, if not nil,
+// for signing keccak256(data) using from. The signature is a valid transaction signature
+// if data is a transaction's signing preimage, so such data is checked as that transaction
+// rather than as a message. Transactions which aren't replay-protected are denied,
+// as the policy can't tell which chain they're for.
+func rawSignPolicyRequest(engine *policy.Engine, from common.Address, data []byte) (*policy.Request, error) {
+	if engine == nil {
+		return &policy.Request{From: from, Message: data}, nil
+	}
+
+	tx, chainID, ok := decodeUnsignedTx(data)
+	if !ok {
+		return &policy.Request{From: from, Message: data}, nil
+	}
+
+	if chainID == nil {
+		return nil, errors.Wrap(policy.ErrDenied, "transactions without replay protection can't be signed")
+	}
+
+	return &policy.Request{From: from, ChainID: chainID, Tx: tx}, nil
+}
@@ -0,0 +1,477 @@
+// Package web3signer serves an hdwallet.HDWallet over Web3Signer's eth1 signing API
+// (see https://consensys.github.io/web3signer/web3signer-eth1.html):
+//
+//	GET  /upcheck                       liveness check
+//	GET  /api/v1/eth1/publicKeys        hex-encoded public keys of the wallet's addresses
+//	POST /api/v1/eth1/sign/{identifier} sign keccak256 of {"data": "0x..."} with the key identified by
+//	                                    its public key or address
+//	POST /                              JSON-RPC: eth_accounts, eth_sign and eth_signTransaction
+//
+// Requests must be authenticated using a bearer token unless the server only listens on
+// loopback addresses or Unix sockets, or authentication is explicitly disabled
+// using WithoutAuthentication. Unauthenticated requests received on loopback addresses
+// must be addressed to a loopback host, so web pages can't reach the server
+// using DNS rebinding. Requests can also be served over TLS
+// and checked against a signing policy.
+package web3signer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"math/big"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/policy"
+)
+
+const (
+	upcheckPath    = "/upcheck"
+	publicKeysPath = "/api/v1/eth1/publicKeys"
+	signPathPrefix = "/api/v1/eth1/sign/"
+
+	// maxRequestBytes limits the size of request bodies.
+	maxRequestBytes int64 = 1 << 20
+)
+
+// Server serves a wallet's derived and imported addresses over Web3Signer's eth1 API.
+type Server struct {
+	wallet    *hdwallet.HDWallet
+	rpcServer *rpc.Server
+	opts      *serverOpts
+
+	mu          sync.Mutex
+	httpServers []*http.Server
+}
+
+type serverOpts struct {
+	chainID         *big.Int
+	bearerToken     string
+	unauthenticated bool
+	tlsConfig       *tls.Config
+	policy          *policy.Engine
+}
+
+type funcServerOpt struct {
+	f func(*serverOpts)
+}
+
+func newFuncServerOpt(f func(*serverOpts)) *funcServerOpt {
+	return &funcServerOpt{f}
+}
+
+func (fo *funcServerOpt) apply(opts *serverOpts) {
+	fo.f(opts)
+}
+
+type ServerOpt interface {
+	apply(*serverOpts)
+}
+
+// WithChainID sets the chain ID used by eth_signTransaction for transactions
+// which don't specify one, like Web3Signer's --chain-id flag.
+// Transactions specifying another chain ID are refused.
+// Defaults to 1 (Ethereum mainnet).
+func WithChainID(chainID *big.Int) ServerOpt {
+	return newFuncServerOpt(func(opts *serverOpts) {
+		opts.chainID = chainID
+	})
+}
+
+// WithBearerToken requires every request other than /upcheck to carry
+// an "Authorization: Bearer <token>" header.
+func WithBearerToken(token string) ServerOpt {
+	return newFuncServerOpt(func(opts *serverOpts) {
+		opts.bearerToken = token
+	})
+}
+
+// WithoutAuthentication allows serving requests without a bearer token on addresses
+// other than loopback addresses, for example behind an authenticating reverse proxy.
+// Without it, such requests are refused unless WithBearerToken is used.
+func WithoutAuthentication() ServerOpt {
+	return newFuncServerOpt(func(opts *serverOpts) {
+		opts.unauthenticated = true
+	})
+}
+
+// WithTLSConfig makes ListenAndServe serve HTTPS using tlsConfig,
+// which must contain at least one certificate.
+func WithTLSConfig(tlsConfig *tls.Config) ServerOpt {
+	return newFuncServerOpt(func(opts *serverOpts) {
+		opts.tlsConfig = tlsConfig
+	})
+}
+
+// WithPolicy checks every signing request against a signing policy.
+// Data sent to the sign endpoint is checked as a message, unless it's the signing preimage
+// of a transaction, which is checked as that transaction and denied if it isn't replay-protected.
+// Denied requests to the sign endpoint get a 403 Forbidden response.
+func WithPolicy(engine *policy.Engine) ServerOpt {
+	return newFuncServerOpt(func(opts *serverOpts) {
+		opts.policy = engine
+	})
+}
+
+func defaultServerOpts() *serverOpts {
+	return &serverOpts{
+		chainID: params.MainnetChainConfig.ChainID,
+	}
+}
+
+// NewServer returns a Server signing with the derived and imported addresses of wallet.
+func NewServer(wallet *hdwallet.HDWallet, opts ...ServerOpt) (*Server, error) {
+	if wallet == nil {
+		return nil, errors.New("wallet must not be nil")
+	}
+
+	sOpts := defaultServerOpts()
+	for _, o := range opts {
+		o.apply(sOpts)
+	}
+
+	if sOpts.chainID == nil || sOpts.chainID.Sign() <= 0 {
+		return nil, errors.Errorf("invalid chain id %v", sOpts.chainID)
+	}
+
+	if sOpts.tlsConfig != nil && len(sOpts.tlsConfig.Certificates) == 0 && sOpts.tlsConfig.GetCertificate == nil {
+		return nil, errors.New("tls config has no certificates")
+	}
+
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("eth", &ethAPI{wallet: wallet, chainID: sOpts.chainID, policy: sOpts.policy}); err != nil {
+		return nil, errors.Wrap(err, "error registering eth api")
+	}
+
+	return &Server{
+		wallet:    wallet,
+		rpcServer: rpcServer,
+		opts:      sOpts,
+	}, nil
+}
+
+// ListenAndServe serves the API on addr, using TLS if configured with WithTLSConfig.
+// addr must be a loopback address such as "127.0.0.1:9000" unless the server
+// requires a bearer token or was created using WithoutAuthentication.
+// It blocks until the server is closed.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "error listening for connections")
+	}
+
+	return s.Serve(l)
+}
+
+// Serve serves the API on l, using TLS if configured with WithTLSConfig.
+// l must listen on a loopback address or a Unix socket unless the server
+// requires a bearer token or was created using WithoutAuthentication.
+// It blocks until the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.opts.authenticated() && !isLocalAddr(l.Addr()) {
+		_ = l.Close()
+		return errors.Errorf("refusing to serve without a bearer token on non-loopback address %q", l.Addr())
+	}
+
+	httpServer := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if s.opts.tlsConfig != nil {
+		l = tls.NewListener(l, s.opts.tlsConfig.Clone())
+	}
+
+	s.mu.Lock()
+	s.httpServers = append(s.httpServers, httpServer)
+	s.mu.Unlock()
+
+	if err := httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Close stops serving requests on every listener passed to Serve.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+
+	for _, httpServer := range s.httpServers {
+		if err := httpServer.Shutdown(context.Background()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	s.rpcServer.Stop()
+
+	s.httpServers = nil
+
+	return firstErr
+}
+
+// ServeHTTP routes requests to the eth1 REST API and the JSON-RPC API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == upcheckPath {
+		if r.Method != http.MethodGet {
+			httpError(w, http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("OK"))
+
+		return
+	}
+
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		httpError(w, http.StatusUnauthorized)
+
+		return
+	}
+
+	if !s.allowedHost(r) {
+		http.Error(w, "invalid host specified", http.StatusForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+
+	switch {
+	case r.URL.Path == publicKeysPath:
+		if r.Method != http.MethodGet {
+			httpError(w, http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.handlePublicKeys(w)
+	case strings.HasPrefix(r.URL.Path, signPathPrefix):
+		if r.Method != http.MethodPost {
+			httpError(w, http.StatusMethodNotAllowed)
+			return
+		}
+
+		// unlike JSON, plain text and form posts can be sent cross-origin by any web page.
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			httpError(w, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		s.handleSign(w, r, strings.TrimPrefix(r.URL.Path, signPathPrefix))
+	case r.URL.Path == "/":
+		s.rpcServer.ServeHTTP(w, r)
+	default:
+		httpError(w, http.StatusNotFound)
+	}
+}
+
+// authorized checks r's bearer token. Without a configured token, only requests
+// received on loopback addresses or Unix sockets are allowed,
+// unless authentication was disabled using WithoutAuthentication.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.opts.bearerToken == "" {
+		if s.opts.unauthenticated {
+			return true
+		}
+
+		localAddr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+
+		return ok && isLocalAddr(localAddr)
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	// compare digests, so the comparison doesn't leak the token's length either.
+	got, want := sha256.Sum256([]byte(token)), sha256.Sum256([]byte(s.opts.bearerToken))
+
+	return subtle.ConstantTimeCompare(got[:], want[:]) == 1
+}
+
+// allowedHost checks r's Host header unless r carries a bearer token or authentication
+// was disabled using WithoutAuthentication: requests received on loopback addresses
+// must be addressed to localhost or a loopback address, as web pages can make browsers
+// send requests to loopback addresses using DNS rebinding, which only changes the Host.
+// Browsers can't connect to Unix sockets, so their requests aren't checked.
+func (s *Server) allowedHost(r *http.Request) bool {
+	if s.opts.authenticated() {
+		return true
+	}
+
+	if _, ok := r.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr); ok {
+		return true
+	}
+
+	return isLoopbackHost(r.Host)
+}
+
+// isLoopbackHost reports whether host, which may include a port,
+// is localhost or a loopback address.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// authenticated reports whether requests from other hosts are either authenticated,
+// or explicitly allowed without authentication.
+func (opts *serverOpts) authenticated() bool {
+	return opts.bearerToken != "" || opts.unauthenticated
+}
+
+// isLocalAddr reports whether addr is a loopback address or a Unix socket.
+func isLocalAddr(addr net.Addr) bool {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	case *net.UnixAddr:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) handlePublicKeys(w http.ResponseWriter) {
+	addrs := s.wallet.Accounts()
+
+	pubKeys := make([]string, len(addrs))
+	for i, addr := range addrs {
+		pubKeys[i] = hexutil.Encode(addr.PublicKeyBytes())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pubKeys)
+}
+
+type signRequest struct {
+	Data hexutil.Bytes `json:"data"`
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request, identifier string) {
+	addr, ok := s.lookup(identifier)
+	if !ok {
+		httpError(w, http.StatusNotFound)
+		return
+	}
+
+	var req signRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policyReq, err := rawSignPolicyRequest(s.opts.policy, addr.Address(), req.Data)
+	if err == nil {
+		err = checkPolicy(r.Context(), s.opts.policy, policyReq)
+	}
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, policy.ErrDenied) {
+			code = http.StatusForbidden
+		}
+
+		http.Error(w, err.Error(), code)
+
+		return
+	}
+
+	sig, err := signHash(addr, crypto.Keccak256(req.Data))
+	policyDone(s.opts.policy, policyReq, err)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(hexutil.Encode(sig)))
+}
+
+// lookup finds the address identified by its hex-encoded public key
+// (with or without the 0x04 prefix) or address.
+func (s *Server) lookup(identifier string) (*hdwallet.HDWalletAddress, bool) {
+	if common.IsHexAddress(identifier) {
+		return s.wallet.FindByAddress(common.HexToAddress(identifier))
+	}
+
+	pubKeyBytes, err := hexutil.Decode(identifier)
+	if err != nil {
+		return nil, false
+	}
+
+	if len(pubKeyBytes) == 64 {
+		pubKeyBytes = append([]byte{0x04}, pubKeyBytes...)
+	}
+
+	pubKey, err := crypto.UnmarshalPubkey(pubKeyBytes)
+	if err != nil {
+		return nil, false
+	}
+
+	return s.wallet.FindByAddress(crypto.PubkeyToAddress(*pubKey))
+}
+
+// signHash signs hash, returning a signature with V = 27 or 28 as Web3Signer does.
+func signHash(signer hdwallet.Signer, hash []byte) ([]byte, error) {
+	sig, err := signer.SignHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	sig[crypto.RecoveryIDOffset] += 27
+
+	return sig, nil
+}
+
+// checkPolicy checks req against engine, if not nil.
+func checkPolicy(ctx context.Context, engine *policy.Engine, req *policy.Request) error {
+	if engine == nil {
+		return nil
+	}
+
+	_, err := engine.Check(ctx, req)
+
+	return err
+}
+
+// policyDone reports whether a request allowed by checkPolicy was signed,
+// so that only signed requests count towards the policy's limits.
+func policyDone(engine *policy.Engine, req *policy.Request, err error) {
+	if engine != nil {
+		engine.Done(req, err)
+	}
+}
+
+func httpError(w http.ResponseWriter, code int) {
+	http.Error(w, http.StatusText(code), code)
+}
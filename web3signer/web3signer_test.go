@@ -0,0 +1,732 @@
+package web3signer_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jalavosus/hdwallet-go"
+	"github.com/jalavosus/hdwallet-go/policy"
+	"github.com/jalavosus/hdwallet-go/web3signer"
+)
+
+const (
+	testMnemonic string = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testToken    string = "s3cr3t"
+)
+
+func newTestWallet(t *testing.T) *hdwallet.HDWallet {
+	t.Helper()
+
+	w, err := hdwallet.NewHDWallet(hdwallet.WithMnemonic(testMnemonic))
+	require.NoError(t, err)
+
+	_, err = w.DeriveRange(0, 3)
+	require.NoError(t, err)
+
+	return w
+}
+
+func newTestServer(t *testing.T, opts ...web3signer.ServerOpt) (*hdwallet.HDWallet, *httptest.Server) {
+	t.Helper()
+
+	w := newTestWallet(t)
+
+	s, err := web3signer.NewServer(w, opts...)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	return w, srv
+}
+
+func doRequest(t *testing.T, client *http.Client, method, url, token string, body any) (int, []byte) {
+	t.Helper()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	require.NoError(t, err)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, respBody
+}
+
+func TestNewServer(t *testing.T) {
+	w := newTestWallet(t)
+
+	tests := []struct {
+		name    string
+		wallet  *hdwallet.HDWallet
+		opts    []web3signer.ServerOpt
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "defaults",
+			wallet:  w,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "nil wallet",
+			wallet:  nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:    "zero chain id",
+			wallet:  w,
+			opts:    []web3signer.ServerOpt{web3signer.WithChainID(big.NewInt(0))},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "tls config without certificates",
+			wallet:  w,
+			opts:    []web3signer.ServerOpt{web3signer.WithTLSConfig(&tls.Config{})},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := web3signer.NewServer(tt.wallet, tt.opts...)
+			tt.wantErr(t, err)
+		})
+	}
+}
+
+func TestServer_PublicKeys(t *testing.T) {
+	w, srv := newTestServer(t)
+
+	status, body := doRequest(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/eth1/publicKeys", "", nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var pubKeys []string
+	require.NoError(t, json.Unmarshal(body, &pubKeys))
+
+	addrs := w.Accounts()
+	require.Len(t, pubKeys, len(addrs))
+
+	for i, addr := range addrs {
+		assert.Equal(t, "0x"+addr.PublicKeyHex(), pubKeys[i])
+	}
+}
+
+func TestServer_Sign(t *testing.T) {
+	w, srv := newTestServer(t)
+
+	addr := w.Accounts()[1]
+	data := []byte("hello web3signer")
+
+	tests := []struct {
+		name       string
+		identifier string
+		wantStatus int
+	}{
+		{
+			name:       "public key",
+			identifier: "0x" + addr.PublicKeyHex(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "public key with 0x04 prefix",
+			identifier: "0x04" + addr.PublicKeyHex(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "address",
+			identifier: addr.Address().Hex(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown address",
+			identifier: common.HexToAddress("0x01").Hex(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid identifier",
+			identifier: "not-a-key",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doRequest(
+				t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/eth1/sign/"+tt.identifier, "",
+				map[string]string{"data": hexutil.Encode(data)},
+			)
+			require.Equal(t, tt.wantStatus, status, string(body))
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			sig, err := hexutil.Decode(string(body))
+			require.NoError(t, err)
+			require.Len(t, sig, crypto.SignatureLength)
+
+			assert.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset])
+
+			sig[crypto.RecoveryIDOffset] -= 27
+
+			pubKey, err := crypto.SigToPub(crypto.Keccak256(data), sig)
+			require.NoError(t, err)
+
+			assert.Equal(t, addr.Address(), crypto.PubkeyToAddress(*pubKey))
+		})
+	}
+
+	t.Run("invalid body", func(t *testing.T) {
+		status, _ := doRequest(
+			t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/eth1/sign/"+addr.Address().Hex(), "",
+			map[string]string{"data": "not hex"},
+		)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		status, _ := doRequest(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/eth1/sign/"+addr.Address().Hex(), "", nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+			req, err := http.NewRequest(
+				http.MethodPost, srv.URL+"/api/v1/eth1/sign/"+addr.Address().Hex(),
+				strings.NewReader(`{"data":"0x1234"}`),
+			)
+			require.NoError(t, err)
+
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode, contentType)
+		}
+	})
+}
+
+func TestServer_BearerToken(t *testing.T) {
+	_, srv := newTestServer(t, web3signer.WithBearerToken(testToken))
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{
+			name:       "upcheck without token",
+			path:       "/upcheck",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "public keys without token",
+			path:       "/api/v1/eth1/publicKeys",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "public keys with wrong token",
+			path:       "/api/v1/eth1/publicKeys",
+			token:      "wrong",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "public keys with token",
+			path:       "/api/v1/eth1/publicKeys",
+			token:      testToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown path with token",
+			path:       "/api/v1/eth2/publicKeys",
+			token:      testToken,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doRequest(t, srv.Client(), http.MethodGet, srv.URL+tt.path, tt.token, nil)
+			assert.Equal(t, tt.wantStatus, status, string(body))
+		})
+	}
+
+	t.Run("json-rpc without token", func(t *testing.T) {
+		status, _ := doRequest(
+			t, srv.Client(), http.MethodPost, srv.URL, "",
+			map[string]any{"jsonrpc": "2.0", "id": 1, "method": "eth_accounts"},
+		)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
+
+func TestServer_TLS(t *testing.T) {
+	w := newTestWallet(t)
+
+	srv := httptest.NewUnstartedServer(nil)
+	srv.StartTLS()
+	tlsConfig := srv.TLS.Clone()
+	client := srv.Client()
+	srv.Close()
+
+	s, err := web3signer.NewServer(w, web3signer.WithTLSConfig(tlsConfig), web3signer.WithBearerToken(testToken))
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(func() { _ = l.Close() })
+
+	url := "https://" + l.Addr().String()
+
+	status, body := doRequest(t, client, http.MethodGet, url+"/api/v1/eth1/publicKeys", testToken, nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var pubKeys []string
+	require.NoError(t, json.Unmarshal(body, &pubKeys))
+	assert.Len(t, pubKeys, len(w.Accounts()))
+
+	// plain HTTP requests must not be served
+	resp, err := http.Get("http://" + l.Addr().String() + "/upcheck")
+	if err == nil {
+		resp.Body.Close()
+		assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestServer_JSONRPC(t *testing.T) {
+	chainID := big.NewInt(5)
+
+	w, srv := newTestServer(t, web3signer.WithChainID(chainID))
+
+	client, err := rpc.DialHTTP(srv.URL)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	addr := w.Accounts()[2]
+
+	t.Run("eth_accounts", func(t *testing.T) {
+		var got []common.Address
+		require.NoError(t, client.Call(&got, "eth_accounts"))
+
+		want := make([]common.Address, 0, len(w.Accounts()))
+		for _, a := range w.Accounts() {
+			want = append(want, a.Address())
+		}
+
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("eth_sign", func(t *testing.T) {
+		msg := []byte("hello json-rpc")
+
+		var sig hexutil.Bytes
+		require.NoError(t, client.Call(&sig, "eth_sign", addr.Address(), hexutil.Bytes(msg)))
+		require.Len(t, sig, crypto.SignatureLength)
+
+		sig[crypto.RecoveryIDOffset] -= 27
+
+		pubKey, err := crypto.SigToPub(accounts.TextHash(msg), sig)
+		require.NoError(t, err)
+
+		assert.Equal(t, addr.Address(), crypto.PubkeyToAddress(*pubKey))
+	})
+
+	t.Run("eth_sign unknown account", func(t *testing.T) {
+		var sig hexutil.Bytes
+		assert.Error(t, client.Call(&sig, "eth_sign", common.HexToAddress("0x01"), hexutil.Bytes("x")))
+	})
+
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantChainID *big.Int
+		wantType    uint8
+		wantErr     assert.ErrorAssertionFunc
+	}{
+		{
+			name: "legacy transaction using server chain id",
+			args: map[string]any{
+				"from":     addr.Address(),
+				"to":       to,
+				"gas":      hexutil.Uint64(21000),
+				"gasPrice": (*hexutil.Big)(big.NewInt(1e9)),
+				"value":    (*hexutil.Big)(big.NewInt(1e18)),
+				"nonce":    hexutil.Uint64(7),
+			},
+			wantChainID: chainID,
+			wantType:    types.LegacyTxType,
+			wantErr:     assert.NoError,
+		},
+		{
+			name: "dynamic fee transaction with chain id",
+			args: map[string]any{
+				"from":                 addr.Address(),
+				"to":                   to,
+				"gas":                  hexutil.Uint64(21000),
+				"maxFeePerGas":         (*hexutil.Big)(big.NewInt(2e9)),
+				"maxPriorityFeePerGas": (*hexutil.Big)(big.NewInt(1e9)),
+				"value":                (*hexutil.Big)(big.NewInt(1)),
+				"nonce":                hexutil.Uint64(0),
+				"chainId":              (*hexutil.Big)(chainID),
+			},
+			wantChainID: chainID,
+			wantType:    types.DynamicFeeTxType,
+			wantErr:     assert.NoError,
+		},
+		{
+			name: "chain id mismatch",
+			args: map[string]any{
+				"from":                 addr.Address(),
+				"to":                   to,
+				"gas":                  hexutil.Uint64(21000),
+				"maxFeePerGas":         (*hexutil.Big)(big.NewInt(2e9)),
+				"maxPriorityFeePerGas": (*hexutil.Big)(big.NewInt(1e9)),
+				"value":                (*hexutil.Big)(big.NewInt(1)),
+				"nonce":                hexutil.Uint64(0),
+				"chainId":              (*hexutil.Big)(big.NewInt(1337)),
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "missing gas price",
+			args: map[string]any{
+				"from":  addr.Address(),
+				"to":    to,
+				"gas":   hexutil.Uint64(21000),
+				"value": (*hexutil.Big)(big.NewInt(1)),
+				"nonce": hexutil.Uint64(0),
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "unknown account",
+			args: map[string]any{
+				"from":     common.HexToAddress("0x01"),
+				"to":       to,
+				"gas":      hexutil.Uint64(21000),
+				"gasPrice": (*hexutil.Big)(big.NewInt(1e9)),
+				"value":    (*hexutil.Big)(big.NewInt(1)),
+				"nonce":    hexutil.Uint64(0),
+			},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("eth_signTransaction "+tt.name, func(t *testing.T) {
+			var raw hexutil.Bytes
+
+			err := client.Call(&raw, "eth_signTransaction", tt.args)
+			if !tt.wantErr(t, err) || err != nil {
+				return
+			}
+
+			tx := new(types.Transaction)
+			require.NoError(t, tx.UnmarshalBinary(raw))
+
+			assert.Equal(t, tt.wantType, tx.Type())
+			assert.Equal(t, tt.wantChainID, tx.ChainId())
+			assert.Equal(t, to, *tx.To())
+
+			sender, err := types.Sender(types.LatestSignerForChainID(tt.wantChainID), tx)
+			require.NoError(t, err)
+
+			assert.Equal(t, addr.Address(), sender)
+		})
+	}
+}
+
+func TestServer_WithPolicy(t *testing.T) {
+	cfg, err := policy.ParseConfig([]byte("recipients: {deny: [0x000000000000000000000000000000000000dEaD]}\nlimits: [{rate_limit: {requests: 1, per: 1h}}]"))
+	require.NoError(t, err)
+
+	engine, err := policy.NewEngine(cfg)
+	require.NoError(t, err)
+
+	w, srv := newTestServer(t, web3signer.WithPolicy(engine))
+
+	addr := w.Accounts()[0]
+	signURL := srv.URL + "/api/v1/eth1/sign/" + addr.Address().Hex()
+	body := map[string]string{"data": "0x1234"}
+
+	status, _ := doRequest(t, srv.Client(), http.MethodPost, signURL, "", body)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, respBody := doRequest(t, srv.Client(), http.MethodPost, signURL, "", body)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, string(respBody), "rate limit")
+
+	client, err := rpc.DialHTTP(srv.URL)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	var raw hexutil.Bytes
+
+	err = client.Call(&raw, "eth_signTransaction", map[string]any{
+		"from":     addr.Address(),
+		"to":       common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+		"gas":      hexutil.Uint64(21000),
+		"gasPrice": (*hexutil.Big)(big.NewInt(1e9)),
+		"nonce":    hexutil.Uint64(0),
+	})
+	assert.ErrorContains(t, err, policy.ErrDenied.Error())
+}
+
+// signingPreimage returns the data whose keccak256 hash is signed when signing tx for chainID,
+// or for no chain if chainID is nil.
+func signingPreimage(t *testing.T, tx *types.Transaction, chainID *big.Int) []byte {
+	t.Helper()
+
+	var fields []any
+
+	switch tx.Type() {
+	case types.LegacyTxType:
+		fields = []any{tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data()}
+		if chainID != nil {
+			fields = append(fields, chainID, uint(0), uint(0))
+		}
+	case types.AccessListTxType:
+		fields = []any{chainID, tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList()}
+	case types.DynamicFeeTxType:
+		fields = []any{chainID, tx.Nonce(), tx.GasTipCap(), tx.GasFeeCap(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList()}
+	}
+
+	data, err := rlp.EncodeToBytes(fields)
+	require.NoError(t, err)
+
+	if tx.Type() != types.LegacyTxType {
+		data = append([]byte{tx.Type()}, data...)
+	}
+
+	hash := types.HomesteadSigner{}.Hash(tx)
+	if chainID != nil {
+		hash = types.LatestSignerForChainID(chainID).Hash(tx)
+	}
+
+	require.Equal(t, hash.Bytes(), crypto.Keccak256(data), "preimage of %d transaction", tx.Type())
+
+	return data
+}
+
+func TestServer_WithPolicy_RawTransaction(t *testing.T) {
+	cfg, err := policy.ParseConfig([]byte("recipients: {deny: [0x000000000000000000000000000000000000dEaD]}"))
+	require.NoError(t, err)
+
+	engine, err := policy.NewEngine(cfg)
+	require.NoError(t, err)
+
+	w, srv := newTestServer(t, web3signer.WithPolicy(engine))
+
+	addr := w.Accounts()[0]
+	signURL := srv.URL + "/api/v1/eth1/sign/" + addr.Address().Hex()
+	chainID := big.NewInt(1)
+
+	allowed := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	denied := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	txs := func(to common.Address) map[string]*types.Transaction {
+		return map[string]*types.Transaction{
+			"legacy": types.NewTx(&types.LegacyTx{
+				Nonce: 1, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1e9),
+			}),
+			"access list": types.NewTx(&types.AccessListTx{
+				ChainID: chainID, Nonce: 1, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1e9),
+			}),
+			"dynamic fee": types.NewTx(&types.DynamicFeeTx{
+				ChainID: chainID, Nonce: 1, To: &to, Value: big.NewInt(1), Gas: 21000,
+				GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(2e9),
+			}),
+		}
+	}
+
+	// transaction preimages are checked as transactions rather than as messages
+	for name, tx := range txs(denied) {
+		status, body := doRequest(t, srv.Client(), http.MethodPost, signURL, "", map[string]string{
+			"data": hexutil.Encode(signingPreimage(t, tx, chainID)),
+		})
+		assert.Equal(t, http.StatusForbidden, status, name)
+		assert.Contains(t, string(body), "recipient "+denied.Hex()+" is denied", name)
+	}
+
+	for name, tx := range txs(allowed) {
+		status, body := doRequest(t, srv.Client(), http.MethodPost, signURL, "", map[string]string{
+			"data": hexutil.Encode(signingPreimage(t, tx, chainID)),
+		})
+		require.Equal(t, http.StatusOK, status, name)
+
+		sig, err := hexutil.Decode(string(body))
+		require.NoError(t, err)
+
+		sig[crypto.RecoveryIDOffset] -= 27
+
+		signer := types.LatestSignerForChainID(chainID)
+
+		signed, err := tx.WithSignature(signer, sig)
+		require.NoError(t, err)
+
+		sender, err := types.Sender(signer, signed)
+		require.NoError(t, err)
+		assert.Equal(t, addr.Address(), sender, name)
+	}
+
+	// unprotected transactions could be replayed on any chain
+	status, body := doRequest(t, srv.Client(), http.MethodPost, signURL, "", map[string]string{
+		"data": hexutil.Encode(signingPreimage(t, txs(allowed)["legacy"], nil)),
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, string(body), "replay protection")
+
+	status, _ = doRequest(t, srv.Client(), http.MethodPost, signURL, "", map[string]string{"data": "0xc0"})
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestServer_RequiresAuthentication(t *testing.T) {
+	w := newTestWallet(t)
+
+	publicKeysRequest := func(localAddr net.Addr, host string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/eth1/publicKeys", nil)
+		if host != "" {
+			r.Host = host
+		}
+
+		return r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, localAddr))
+	}
+
+	tests := []struct {
+		name       string
+		opts       []web3signer.ServerOpt
+		localAddr  net.Addr
+		host       string
+		wantStatus int
+		wantServe  bool
+	}{
+		{
+			name:       "no token on loopback",
+			localAddr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)},
+			host:       "127.0.0.1:9000",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no token on loopback using localhost",
+			localAddr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)},
+			host:       "localhost:9000",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no token on loopback with another host (dns rebinding)",
+			localAddr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)},
+			host:       "attacker.example:9000",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "no token on unix socket",
+			localAddr:  &net.UnixAddr{Name: "/tmp/web3signer.sock", Net: "unix"},
+			host:       "web3signer",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no token on other address",
+			localAddr:  &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1)},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "without authentication",
+			opts:       []web3signer.ServerOpt{web3signer.WithoutAuthentication()},
+			localAddr:  &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1)},
+			wantStatus: http.StatusOK,
+			wantServe:  true,
+		},
+		{
+			name:       "bearer token",
+			opts:       []web3signer.ServerOpt{web3signer.WithBearerToken(testToken)},
+			localAddr:  &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1)},
+			wantStatus: http.StatusUnauthorized,
+			wantServe:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := web3signer.NewServer(w, tt.opts...)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, publicKeysRequest(tt.localAddr, tt.host))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			l, err := net.Listen("tcp", "0.0.0.0:0")
+			require.NoError(t, err)
+
+			if !tt.wantServe {
+				assert.Error(t, s.Serve(l))
+				return
+			}
+
+			served := make(chan error, 1)
+			go func() { served <- s.Serve(l) }()
+
+			require.Eventually(t, func() bool {
+				resp, err := http.Get("http://" + l.Addr().String() + "/upcheck")
+				if err != nil {
+					return false
+				}
+
+				resp.Body.Close()
+
+				return resp.StatusCode == http.StatusOK
+			}, 5*time.Second, 10*time.Millisecond)
+
+			require.NoError(t, s.Close())
+			assert.NoError(t, <-served)
+		})
+	}
+}